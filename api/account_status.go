@@ -0,0 +1,130 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+)
+
+var errSweepToSameAccount = errors.New("sweepToAccountId must be another account")
+
+type changeAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// changeAccountStatus freezes or unfreezes an account. Only the admins change it, otherwise the owner
+// of an account frozen for suspicious activity could unfreeze it.
+func (s *Server) changeAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req changeAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	account, err := s.store.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusTxParams{
+		AccountID: uri.Id,
+		Status:    req.Status,
+		Reason:    req.Reason,
+		ChangedBy: authPayload.Email,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInvalidStatusTransition) || errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.newAccountResponse(ctx, account))
+}
+
+type closeAccountRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// SweepToAccountId receives the balance left, without it the account must be empty
+	SweepToAccountId int64 `json:"sweepToAccountId" binding:"omitempty,min=1"`
+}
+
+// closeAccount closes an account of the authenticated user for good, the balance left can be swept
+// to a nominated account in its currency, of the user or anyone else
+func (s *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req closeAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.SweepToAccountId == uri.Id {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errSweepToSameAccount))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	if req.SweepToAccountId != 0 {
+		_, valid := s.validAccount(ctx, req.SweepToAccountId, account.Currency, recipient)
+		if !valid {
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	result, err := s.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:        account.ID,
+		SweepToAccountID: req.SweepToAccountId,
+		Reason:           req.Reason,
+		ChangedBy:        authPayload.Email,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotEmpty) || errors.Is(err, db.ErrAccountClosed) || errors.Is(err, db.ErrAccountFrozen) ||
+			errors.Is(err, db.ErrLimitExceeded) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := closeAccountResponse{Account: s.newAccountResponse(ctx, result.Account)}
+	if result.Sweep.Transfer.ID != 0 {
+		rsp.Sweep = s.newTransferTxResponse(ctx, result.Sweep)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// closeAccountResponse is the db.CloseAccountTxResult with the amounts written for the locale of the request.
+// The Sweep is empty when there was no balance left.
+type closeAccountResponse struct {
+	Account accountResponse
+	Sweep   transferTxResponse
+}
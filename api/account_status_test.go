@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeAccountStatusAPI(t *testing.T) {
+	admin := util.RandomOwner()
+	user := util.RandomOwner()
+	account := mockAccount(user)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Freeze",
+			body: gin.H{"status": db.AccountFrozen, "reason": "suspicious activity"},
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountFrozen,
+					Reason:    "suspicious activity",
+					ChangedBy: admin,
+				}
+				frozen := account
+				frozen.Status = db.AccountFrozen
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.Accounts
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.AccountFrozen, got.Status)
+			},
+		},
+		{
+			name: "OwnerUnfreeze",
+			body: gin.H{"status": db.AccountActive, "reason": "it was me"},
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"status": db.AccountFrozen, "reason": "suspicious activity"},
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Accounts{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "ClosedStatus",
+			body: gin.H{"status": db.AccountClosed, "reason": "moving abroad"},
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoReason",
+			body: gin.H{"status": db.AccountFrozen},
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidTransition",
+			body: gin.H{"status": db.AccountActive, "reason": "card found"},
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Accounts{}, fmt.Errorf("%w: account %d", db.ErrInvalidStatusTransition, account.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AdminEmails = []string{admin}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/status", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCloseAccountAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := mockAccount(user1)
+	account1.ID = 1
+	account1.Currency = util.USD
+	account2 := mockAccount(user2)
+	account2.ID = 2
+	account2.Currency = util.USD
+	account3 := mockAccount(user1)
+	account3.ID = 3
+	account3.Currency = util.EUR
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "SweepToOtherOwner",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account2.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CloseAccountTxParams{
+					AccountID:        account1.ID,
+					SweepToAccountID: account2.ID,
+					Reason:           "moving abroad",
+					ChangedBy:        user1,
+				}
+				closed := account1
+				closed.Status = db.AccountClosed
+				closed.Balance = 0
+				sweep := db.TransferTxResult{
+					Transfer:    db.Transfers{ID: 7, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: account1.Balance},
+					FromAccount: closed,
+					ToAccount:   account2,
+				}
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.CloseAccountTxResult{Account: closed, Sweep: sweep}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got closeAccountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.AccountClosed, got.Account.Status)
+				require.NotEmpty(t, got.Account.BalanceDisplay)
+				require.Equal(t, int64(7), got.Sweep.Transfer.ID)
+				require.NotEmpty(t, got.Sweep.Transfer.AmountDisplay)
+			},
+		},
+		{
+			name: "SweepOverLimit",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account2.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, &db.LimitExceededError{Amount: account1.Balance})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "SweepToClosed",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account2.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				closed := account2
+				closed.Status = db.AccountClosed
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(closed, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "SweepFrozen",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account2.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := account1
+				frozen.Status = db.AccountFrozen
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, fmt.Errorf("%w: account %d can't send money", db.ErrAccountFrozen, account1.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "NotEmpty",
+			body: gin.H{"reason": "moving abroad"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, fmt.Errorf("%w: account %d", db.ErrAccountNotEmpty, account1.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "SweepToSameAccount",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account1.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SweepCurrencyMismatch",
+			body: gin.H{"reason": "moving abroad", "sweepToAccountId": account3.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/close", account1.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
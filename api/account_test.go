@@ -9,25 +9,29 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/homocode/bank_demo/api/mock"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
 	"github.com/homocode/bank_demo/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
 func TestCreateAccountApi(t *testing.T) {
+	owner := util.RandomOwner()
 	reqBody := createAccountRequest{
-		Owner:    util.RandomOwner(),
 		Currency: util.RandomCurrency(),
 	}
 
 	// this is to pass it to Return in the store.EXPECT() to match the type
-	account := mockAccount("")
+	account := mockAccount(owner)
 
+	// the owner is taken from the authenticated user, not from the request
 	arg := db.CreateAccountParams{
-		Owner:    reqBody.Owner,
+		Owner:    owner,
 		Currency: reqBody.Currency,
 		Balance:  0,
 	}
@@ -35,12 +39,16 @@ func TestCreateAccountApi(t *testing.T) {
 	testCases := []struct {
 		name          string
 		reqBody       createAccountRequest
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:    "Ok",
 			reqBody: reqBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Eq(arg)).
@@ -55,9 +63,11 @@ func TestCreateAccountApi(t *testing.T) {
 		{
 			name: "Invalid Request",
 			reqBody: createAccountRequest{
-				Owner:    "",
 				Currency: "something",
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
@@ -67,9 +77,58 @@ func TestCreateAccountApi(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:    "No Authorization",
+			reqBody: reqBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "Duplicate Currency",
+			reqBody: reqBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Accounts{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:    "Owner Not Found",
+			reqBody: reqBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Accounts{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name:    "Internal Server Error",
 			reqBody: reqBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Eq(arg)).
@@ -96,7 +155,7 @@ func TestCreateAccountApi(t *testing.T) {
 			tc.buildStubs(store)
 
 			// start test server and send request
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			// marshal body data to JSON
@@ -106,6 +165,8 @@ func TestCreateAccountApi(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(jsonBody))
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
+
 			// check response
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -124,26 +185,46 @@ func TestGetAccountApi(t *testing.T) {
 	testCases := []struct {
 		name          string
 		reqParams     getAccountRequest
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:      "Ok",
 			reqParams: reqParams,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(reqParams.Id)).
 					Times(1).
 					Return(account, nil)
+				store.EXPECT().
+					SumActiveHolds(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(int64(1), nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyToMatachAccount(t, recorder.Body, account)
+
+				var rsp accountDetailResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, account, rsp.Accounts)
+				require.Equal(t, account.Balance-1, rsp.AvailableBalance)
+
+				currency, _ := util.NewCurrencyRegistry(util.DefaultCurrencies).Get(account.Currency)
+				require.Equal(t, util.NewMoney(account.Balance, currency).Format(util.LocaleEnglish), rsp.BalanceDisplay)
+				require.Equal(t, util.NewMoney(account.Balance-1, currency).Format(util.LocaleEnglish), rsp.AvailableBalanceDisplay)
 			},
 		},
 		{
 			name:      "Invalid Request",
 			reqParams: getAccountRequest{Id: 0},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Any()).
@@ -153,9 +234,42 @@ func TestGetAccountApi(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:      "No Authorization",
+			reqParams: reqParams,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "Unauthorized User",
+			reqParams: reqParams,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.RandomOwner(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(reqParams.Id)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name:      "Not Found",
 			reqParams: getAccountRequest{Id: account.ID},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(reqParams.Id)).
@@ -170,6 +284,9 @@ func TestGetAccountApi(t *testing.T) {
 		{
 			name:      "Internal Server Error",
 			reqParams: reqParams,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -196,13 +313,15 @@ func TestGetAccountApi(t *testing.T) {
 			tc.buildStubs(store)
 
 			// start test server and send request
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", tc.reqParams.Id)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
+
 			// check response
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -222,13 +341,12 @@ func TestListAccountsAPI(t *testing.T) {
 	}
 
 	reqQuery := listAccountsRequest{
-		Owner:    owner,
 		PageId:   1,
 		PageSize: int32(pageSize),
 	}
 
 	arg := db.ListAccountsParams{
-		Owner:  reqQuery.Owner,
+		Owner:  owner,
 		Limit:  reqQuery.PageSize,
 		Offset: (reqQuery.PageSize * reqQuery.PageId) - reqQuery.PageSize,
 	}
@@ -236,12 +354,16 @@ func TestListAccountsAPI(t *testing.T) {
 	testCases := []struct {
 		name          string
 		reqQuery      listAccountsRequest
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:     "Ok",
 			reqQuery: reqQuery,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
@@ -253,13 +375,29 @@ func TestListAccountsAPI(t *testing.T) {
 				requireBodyToMatachAccounts(t, recorder.Body, accounts)
 			},
 		},
+		{
+			name:     "No Authorization",
+			reqQuery: reqQuery,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name: "Invalid Request",
 			reqQuery: listAccountsRequest{
-				Owner:    "",
 				PageId:   0,
 				PageSize: 2,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
@@ -272,6 +410,9 @@ func TestListAccountsAPI(t *testing.T) {
 		{
 			name:     "Not Found",
 			reqQuery: reqQuery,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
@@ -285,6 +426,9 @@ func TestListAccountsAPI(t *testing.T) {
 		{
 			name:     "Internal Server Error",
 			reqQuery: reqQuery,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, owner, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
@@ -310,7 +454,7 @@ func TestListAccountsAPI(t *testing.T) {
 			tc.buildStubs(store)
 
 			// start test server and send request
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := "/accounts"
@@ -319,11 +463,12 @@ func TestListAccountsAPI(t *testing.T) {
 
 			// add query parameters to request URL
 			q := request.URL.Query()
-			q.Add("owner", fmt.Sprintf("%v", tc.reqQuery.Owner))
 			q.Add("page_id", fmt.Sprintf("%d", tc.reqQuery.PageId))
 			q.Add("page_size", fmt.Sprintf("%d", tc.reqQuery.PageSize))
 			request.URL.RawQuery = q.Encode()
 
+			tc.setupAuth(t, request, server.tokenMaker)
+
 			// check response
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -334,10 +479,13 @@ func TestListAccountsAPI(t *testing.T) {
 
 func mockAccount(owner string) db.Accounts {
 	return db.Accounts{
-		ID:       util.RandomInt(1, 100),
-		Owner:    owner,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		ID:            util.RandomInt(1, 100),
+		Owner:         owner,
+		Balance:       util.RandomMoney(),
+		Currency:      util.RandomCurrency(),
+		Status:        db.AccountActive,
+		Tier:          db.AccountTierStandard,
+		AccountNumber: util.RandomAccountNumber(),
 	}
 }
 
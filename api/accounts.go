@@ -2,14 +2,21 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+	"github.com/lib/pq"
 )
 
+// errAccountNotOwned is returned when the authenticated user tries to access an account
+// that exists but belongs to another user. Accounts that don't exist are reported with 404,
+// accounts owned by someone else with 403.
+var errAccountNotOwned = errors.New("account doesn't belong to the authenticated user")
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
@@ -21,19 +28,69 @@ func (s *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idem, idempotent, err := s.idempotencyParams(ctx, authPayload.Email, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Email,
 		Currency: req.Currency,
 		Balance:  0,
 	}
 
-	account, err := s.store.CreateAccount(ctx, arg)
+	var account db.Accounts
+	if idempotent {
+		var replayed bool
+		account, replayed, err = s.store.CreateAccountIdempotent(ctx, idem, arg)
+		if replayed {
+			ctx.Header(idempotentReplayedHeader, "true")
+		}
+	} else {
+		account, err = s.store.CreateAccount(ctx, arg)
+	}
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+				return
+			case "foreign_key_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, s.newAccountResponse(ctx, account))
+}
+
+// accountResponse adds to the account its balance written for the locale of the request
+type accountResponse struct {
+	db.Accounts
+	BalanceDisplay string `json:"balance_display"`
+}
+
+func (s *Server) newAccountResponse(ctx *gin.Context, account db.Accounts) accountResponse {
+	return accountResponse{
+		Accounts:       account,
+		BalanceDisplay: s.display(ctx, account.Balance, account.Currency),
+	}
+}
+
+// accountDetailResponse adds the part of the balance not reserved by holds
+type accountDetailResponse struct {
+	accountResponse
+	AvailableBalance        int64  `json:"available_balance"`
+	AvailableBalanceDisplay string `json:"available_balance_display"`
 }
 
 type getAccountRequest struct {
@@ -48,23 +105,52 @@ func (s *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	account, err := s.store.GetAccount(ctx, req.Id)
+	account, valid := s.ownedAccount(ctx, req.Id)
+	if !valid {
+		return
+	}
+
+	held, err := s.store.SumActiveHolds(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountDetailResponse{
+		accountResponse:         s.newAccountResponse(ctx, account),
+		AvailableBalance:        account.Balance - held,
+		AvailableBalanceDisplay: s.display(ctx, account.Balance-held, account.Currency),
+	})
+}
+
+// ownedAccount gets the account when it belongs to the authenticated user,
+// otherwise it writes the error response and returns false
+func (s *Server) ownedAccount(ctx *gin.Context, accountId int64) (db.Accounts, bool) {
+	account, err := s.store.GetAccount(ctx, accountId)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+			return account, false
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return account, false
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	return account, true
 }
 
+// listAccountsRequest pages with Cursor, the next_cursor of the previous page.
+// PageId is deprecated, it's kept for the clients still paging by offset.
 type listAccountsRequest struct {
-	Owner    string `form:"owner" binding:"required"`
-	PageId   int32  `form:"page_id" binding:"required,gt=0"`
+	PageId   int32  `form:"page_id" binding:"omitempty,gt=0"`
 	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor" binding:"excluded_with=PageId"`
 }
 
 func (s *Server) listAccounts(ctx *gin.Context) {
@@ -75,8 +161,51 @@ func (s *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
+	if req.PageId != 0 {
+		s.listAccountsByOffset(ctx, req)
+		return
+	}
+
+	after, err := s.decodeCursor(ctx, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListAccountsAfterParams{
+		Owner:          authPayload.Email,
+		AfterCreatedAt: after.CreatedAt,
+		AfterID:        after.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	accounts, err := s.store.ListAccountsAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := newListResponse(s, ctx, accounts, req.PageSize, func(account db.Accounts) pageCursor {
+		return pageCursor{CreatedAt: account.CreatedAt.Time, ID: account.ID}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapListResponse(rsp, func(account db.Accounts) accountResponse {
+		return s.newAccountResponse(ctx, account)
+	}))
+}
+
+// listAccountsByOffset is the deprecated page_id pagination, it answers with the bare list
+func (s *Server) listAccountsByOffset(ctx *gin.Context, req listAccountsRequest) {
+	ctx.Header(deprecationHeader, "true")
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.ListAccountsParams{
-		Owner:  req.Owner,
+		Owner:  authPayload.Email,
 		Limit:  req.PageSize,
 		Offset: (req.PageSize * req.PageId) - req.PageSize,
 	}
@@ -91,5 +220,7 @@ func (s *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, mapItems(accounts, func(account db.Accounts) accountResponse {
+		return s.newAccountResponse(ctx, account)
+	}))
 }
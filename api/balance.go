@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+// maxBalanceHistoryDays is the longest range of a balance history, a year
+const maxBalanceHistoryDays = 366
+
+var errBalanceHistoryTooLong = errors.New("balance history can't span more than 366 days")
+
+// balanceRequest asks for the balance at an RFC 3339 time, now when At is missing
+type balanceRequest struct {
+	At time.Time `form:"at"`
+}
+
+type balanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	At        time.Time `json:"at"`
+	Balance   int64     `json:"balance"`
+}
+
+// getBalance answers with the balance an account of the authenticated user had at a point in time,
+// made of the entries created before it
+func (s *Server) getBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req balanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	at := req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	balance, err := s.store.GetBalanceAt(ctx, db.GetBalanceAtParams{
+		AccountID: account.ID,
+		At:        at,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, balanceResponse{
+		AccountID: account.ID,
+		At:        at,
+		Balance:   balance,
+	})
+}
+
+// balanceHistoryRequest is a range of days, in UTC and both included. Day is the only interval.
+type balanceHistoryRequest struct {
+	From     time.Time `form:"from" time_format:"2006-01-02" time_utc:"1" binding:"required"`
+	To       time.Time `form:"to" time_format:"2006-01-02" time_utc:"1" binding:"required,gtefield=From"`
+	Interval string    `form:"interval" binding:"omitempty,oneof=day"`
+}
+
+// getBalanceHistory lists the balance an account of the authenticated user had at the end of each day
+func (s *Server) getBalanceHistory(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req balanceHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.To.Sub(req.From) >= maxBalanceHistoryDays*24*time.Hour {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errBalanceHistoryTooLong))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	history, err := s.store.BalanceHistory(ctx, account.ID, req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	account := mockAccount(user1)
+	at := time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		user          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			user:  user1,
+			query: url.Values{"at": {at.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.GetBalanceAtParams{AccountID: account.ID, At: at}
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(150), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got balanceResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, int64(150), got.Balance)
+				require.True(t, at.Equal(got.At))
+			},
+		},
+		{
+			name:  "Now",
+			user:  user1,
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.GetBalanceAtParams) (int64, error) {
+						require.WithinDuration(t, time.Now(), arg.At, time.Second)
+						return account.Balance, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidAt",
+			user:  user1,
+			query: url.Values{"at": {"March 3rd"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AccountNotOwned",
+			user:  user2,
+			query: url.Values{"at": {at.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			user:  user1,
+			query: url.Values{"at": {at.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/balance?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetBalanceHistoryAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	account := mockAccount(user1)
+
+	from := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC)
+	history := []db.DailyBalance{
+		{Day: from, Balance: 100},
+		{Day: to, Balance: 80},
+	}
+
+	testCases := []struct {
+		name          string
+		user          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			user:  user1,
+			query: url.Values{"from": {"2023-03-01"}, "to": {"2023-03-02"}, "interval": {"day"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Eq(account.ID), gomock.Eq(from), gomock.Eq(to)).
+					Times(1).Return(history, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.DailyBalance
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, history, got)
+			},
+		},
+		{
+			name:  "ToBeforeFrom",
+			user:  user1,
+			query: url.Values{"from": {"2023-03-02"}, "to": {"2023-03-01"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "TooLong",
+			user:  user1,
+			query: url.Values{"from": {"2022-03-01"}, "to": {"2023-03-02"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UnsupportedInterval",
+			user:  user1,
+			query: url.Values{"from": {"2023-03-01"}, "to": {"2023-03-02"}, "interval": {"week"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AccountNotOwned",
+			user:  user2,
+			query: url.Values{"from": {"2023-03-01"}, "to": {"2023-03-02"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			user:  user1,
+			query: url.Values{"from": {"2023-03-01"}, "to": {"2023-03-02"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BalanceHistory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/balance-history?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+	"github.com/homocode/bank_demo/util"
+)
+
+// Modes of a batch of transfers
+const (
+	// batchAtomic books all the transfers or none
+	batchAtomic = "atomic"
+	// batchBestEffort books each transfer on its own, the ones that fail don't stop the rest
+	batchBestEffort = "best_effort"
+)
+
+type batchTransferRequest struct {
+	Mode      string            `json:"mode" binding:"required,oneof=atomic best_effort"`
+	Transfers []transferRequest `json:"transfers" binding:"required,min=1,max=500,dive"`
+}
+
+// batchTransferItem is the result of a transfer of the batch, Transfer is set when Status is 200
+// and Error otherwise
+type batchTransferItem struct {
+	Index    int                 `json:"index"`
+	Status   int                 `json:"status"`
+	Transfer *transferTxResponse `json:"transfer,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// batchTransfer books a list of transfers out of accounts of the authenticated user. In atomic mode
+// the first transfer that fails is answered with its index and none is booked, in best effort mode
+// the answer has the result of each transfer.
+func (s *Server) batchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accounts := make(map[int64]db.Accounts)
+	items := make([]batchTransferItem, len(req.Transfers))
+	args := make([]db.TransferTxParams, len(req.Transfers))
+
+	for i := range req.Transfers {
+		items[i].Index = i
+		transfer := &req.Transfers[i]
+
+		status, err := s.checkBatchTransfer(ctx, accounts, transfer, authPayload.Email)
+		if err != nil {
+			if req.Mode == batchAtomic {
+				ctx.JSON(status, batchErrorResponse(err, i))
+				return
+			}
+			items[i].Status = status
+			items[i].Error = err.Error()
+			continue
+		}
+
+		args[i] = db.TransferTxParams{
+			FromAccountId: transfer.FromAccountId,
+			ToAccountId:   transfer.ToAccountId,
+			Amount:        transfer.Amount,
+			QuoteID:       transfer.QuoteId,
+		}
+	}
+
+	if req.Mode == batchAtomic {
+		results, err := s.store.BatchTransferTx(ctx, args)
+		if err != nil {
+			var batchErr *db.BatchTransferError
+			if errors.As(err, &batchErr) {
+				ctx.JSON(transferErrorStatus(err), batchErrorResponse(err, batchErr.Index))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for i := range results {
+			items[i].Status = http.StatusOK
+			transfer := s.newTransferTxResponse(ctx, results[i])
+			items[i].Transfer = &transfer
+		}
+		ctx.JSON(http.StatusOK, items)
+		return
+	}
+
+	for i := range items {
+		if items[i].Error != "" {
+			continue
+		}
+
+		result, err := s.store.TransferTx(ctx, args[i])
+		if err != nil {
+			items[i].Status = transferErrorStatus(err)
+			items[i].Error = err.Error()
+			continue
+		}
+		items[i].Status = http.StatusOK
+		transfer := s.newTransferTxResponse(ctx, result)
+		items[i].Transfer = &transfer
+	}
+
+	ctx.JSON(http.StatusOK, items)
+}
+
+// checkBatchTransfer makes the checks of transferBtwAccounts on a transfer of the batch and returns
+// the error with the status of the response. accounts caches the accounts got by previous transfers,
+// a payroll sends them all from the same account. Quotes are checked by TransferTx.
+// Like validToAccount it sets the ToAccountId of a transfer to an account number, and the Amount
+// of one sent with AmountDecimal.
+func (s *Server) checkBatchTransfer(ctx *gin.Context, accounts map[int64]db.Accounts, transfer *transferRequest, owner string) (int, error) {
+	if err := s.parseAmount(transfer); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	fromAccount, err := s.batchAccount(ctx, accounts, transfer.FromAccountId)
+	if err != nil {
+		return accountErrorStatus(err), err
+	}
+
+	if fromAccount.Owner != owner {
+		return http.StatusForbidden, errAccountNotOwned
+	}
+
+	if status, err := checkAccount(fromAccount, transfer.Currency, sender); err != nil {
+		return status, err
+	}
+
+	var toAccount db.Accounts
+	if transfer.ToAccountNumber != "" {
+		toAccount, err = s.store.GetAccountByNumber(ctx, util.NormalizeAccountNumber(transfer.ToAccountNumber))
+		if err == nil {
+			transfer.ToAccountId = toAccount.ID
+			accounts[toAccount.ID] = toAccount
+		}
+	} else {
+		toAccount, err = s.batchAccount(ctx, accounts, transfer.ToAccountId)
+	}
+	if err != nil {
+		return accountErrorStatus(err), err
+	}
+
+	// the quote converts to the currency of the ToAccount
+	toCurrency := transfer.Currency
+	if transfer.QuoteId != 0 {
+		toCurrency = toAccount.Currency
+	}
+
+	return checkAccount(toAccount, toCurrency, recipient)
+}
+
+// batchAccount gets the account from accounts or the store
+func (s *Server) batchAccount(ctx *gin.Context, accounts map[int64]db.Accounts, id int64) (db.Accounts, error) {
+	if account, ok := accounts[id]; ok {
+		return account, nil
+	}
+
+	account, err := s.store.GetAccount(ctx, id)
+	if err != nil {
+		return account, err
+	}
+
+	accounts[id] = account
+	return account, nil
+}
+
+// accountErrorStatus is the status of the response when an account couldn't be got
+func accountErrorStatus(err error) int {
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+// batchErrorResponse adds to the error the index of the transfer that failed
+func batchErrorResponse(err error, index int) gin.H {
+	rsp := errorResponse(err)
+	rsp["index"] = index
+	return rsp
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	amount := int64(10)
+
+	employer := util.RandomOwner()
+	employee1 := util.RandomOwner()
+	employee2 := util.RandomOwner()
+
+	account := mockAccount(employer)
+	account.ID = 1
+	account.Currency = util.USD
+	account1 := mockAccount(employee1)
+	account1.ID = 2
+	account1.Currency = util.USD
+	account2 := mockAccount(employee2)
+	account2.ID = 3
+	account2.Currency = util.USD
+
+	payroll := []gin.H{
+		{"fromAccountId": account.ID, "toAccountId": account1.ID, "amount": amount, "currency": util.USD},
+		{"fromAccountId": account.ID, "toAccountId": account2.ID, "amount": amount, "currency": util.USD},
+	}
+	args := []db.TransferTxParams{
+		{FromAccountId: account.ID, ToAccountId: account1.ID, Amount: amount},
+		{FromAccountId: account.ID, ToAccountId: account2.ID, Amount: amount},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Atomic",
+			body: gin.H{"mode": batchAtomic, "transfers": payroll},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				// the source account is got once for the whole batch
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(make([]db.TransferTxResult, len(args)), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var items []batchTransferItem
+				err := json.Unmarshal(recorder.Body.Bytes(), &items)
+				require.NoError(t, err)
+				require.Len(t, items, 2)
+				for i, item := range items {
+					require.Equal(t, i, item.Index)
+					require.Equal(t, http.StatusOK, item.Status)
+					require.NotNil(t, item.Transfer)
+				}
+			},
+		},
+		{
+			name: "AtomicAccountNumbers",
+			body: gin.H{"mode": batchAtomic, "transfers": []gin.H{
+				{"fromAccountId": account.ID, "toAccountNumber": account1.AccountNumber, "amount": amount, "currency": util.USD},
+				{"fromAccountId": account.ID, "toAccountId": account2.ID, "amount": amount, "currency": util.USD},
+			}},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account1.AccountNumber)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(args)).
+					Times(1).
+					Return(make([]db.TransferTxResult, len(args)), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AtomicAccountNotOwned",
+			body: gin.H{"mode": batchAtomic, "transfers": []gin.H{
+				payroll[0],
+				{"fromAccountId": account1.ID, "toAccountId": account2.ID, "amount": amount, "currency": util.USD},
+			}},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBatchErrorIndex(t, recorder, 1)
+			},
+		},
+		{
+			name: "AtomicInsufficientFunds",
+			body: gin.H{"mode": batchAtomic, "transfers": payroll},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ any, id int64) (db.Accounts, error) {
+					return map[int64]db.Accounts{account.ID: account, account1.ID: account1, account2.ID: account2}[id], nil
+				})
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &db.BatchTransferError{Index: 1, Err: fmt.Errorf("%w: account %d", db.ErrInsufficientFunds, account.ID)})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireBatchErrorIndex(t, recorder, 1)
+			},
+		},
+		{
+			name: "BestEffort",
+			body: gin.H{"mode": batchBestEffort, "transfers": []gin.H{
+				payroll[0],
+				{"fromAccountId": account.ID, "toAccountId": 100, "amount": amount, "currency": util.USD},
+				payroll[1],
+			}},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(100))).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(args[0])).Times(1).Return(db.TransferTxResult{}, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(args[1])).
+					Times(1).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w: account %d", db.ErrInsufficientFunds, account.ID))
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var items []batchTransferItem
+				err := json.Unmarshal(recorder.Body.Bytes(), &items)
+				require.NoError(t, err)
+				require.Len(t, items, 3)
+				require.Equal(t, http.StatusOK, items[0].Status)
+				require.Equal(t, http.StatusNotFound, items[1].Status)
+				require.NotEmpty(t, items[1].Error)
+				require.Equal(t, http.StatusUnprocessableEntity, items[2].Status)
+				require.Nil(t, items[2].Transfer)
+			},
+		},
+		{
+			name: "InvalidMode",
+			body: gin.H{"mode": "some", "transfers": payroll},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidItem",
+			body: gin.H{"mode": batchAtomic, "transfers": []gin.H{
+				{"fromAccountId": account.ID, "toAccountId": account1.ID, "amount": 0, "currency": util.USD},
+			}},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyBatch",
+			body: gin.H{"mode": batchAtomic, "transfers": []gin.H{}},
+			user: employer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireBatchErrorIndex(t *testing.T, recorder *httptest.ResponseRecorder, index int) {
+	t.Helper()
+
+	var rsp struct {
+		Error string `json:"error"`
+		Index int    `json:"index"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.Error)
+	require.Equal(t, index, rsp.Index)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+type cashRequest struct {
+	Amount   int64  `json:"amount" binding:"required,amount"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+func (s *Server) depositToAccount(ctx *gin.Context) {
+	s.cashOperation(ctx, recipient, s.store.DepositTx)
+}
+
+func (s *Server) withdrawFromAccount(ctx *gin.Context) {
+	s.cashOperation(ctx, sender, s.store.WithdrawTx)
+}
+
+// cashOperation runs a deposit or a withdrawal with tx on an account, role is the side the account
+// takes in it. The teller, an admin, books it on the account of the customer.
+func (s *Server) cashOperation(ctx *gin.Context, role accountRole, tx func(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.validAccount(ctx, uri.Id, req.Currency, role)
+	if !valid {
+		return
+	}
+
+	result, err := tx(ctx, db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.newCashTxResponse(ctx, result))
+}
+
+// cashTxResponse is the db.CashTxResult with the amounts written for the locale of the request
+type cashTxResponse struct {
+	Account     accountResponse
+	Entry       entryResponse
+	CashAccount accountResponse
+	CashEntry   entryResponse
+}
+
+func (s *Server) newCashTxResponse(ctx *gin.Context, result db.CashTxResult) cashTxResponse {
+	return cashTxResponse{
+		Account:     s.newAccountResponse(ctx, result.Account),
+		Entry:       s.newEntryResponse(ctx, result.Entry, result.Account.Currency),
+		CashAccount: s.newAccountResponse(ctx, result.CashAccount),
+		CashEntry:   s.newEntryResponse(ctx, result.CashEntry, result.CashAccount.Currency),
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+type currencyRequest struct {
+	// Code is taken in any case, the registry has them in uppercase
+	Code string `uri:"code" binding:"required,alpha,len=3"`
+}
+
+// listCurrencies answers with the currencies the server knows, enabled or not
+func (s *Server) listCurrencies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.currencies.List())
+}
+
+// enableCurrency lets accounts and transfers be created in the currency, the cash and revenue
+// accounts of the currency are created with it when it doesn't have them
+func (s *Server) enableCurrency(ctx *gin.Context) {
+	s.setCurrencyEnabled(ctx, s.store.EnableCurrencyTx)
+}
+
+// disableCurrency stops accounts and transfers being created in the currency,
+// the accounts that have it keep their balance
+func (s *Server) disableCurrency(ctx *gin.Context) {
+	s.setCurrencyEnabled(ctx, func(ctx context.Context, code string) (db.Currencies, error) {
+		return s.store.SetCurrencyEnabled(ctx, db.SetCurrencyEnabledParams{
+			Code:    code,
+			Enabled: false,
+		})
+	})
+}
+
+// setCurrencyEnabled updates the currency in the database with set and in the registry of the server,
+// the other servers get it on their next refresh
+func (s *Server) setCurrencyEnabled(ctx *gin.Context, set func(ctx context.Context, code string) (db.Currencies, error)) {
+	var uri currencyRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	currency, err := set(ctx, strings.ToUpper(uri.Code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	s.currencies.Set(currency.RegistryCurrency())
+	ctx.JSON(http.StatusOK, currency)
+}
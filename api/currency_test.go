@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCurrencyEnabledAPI(t *testing.T) {
+	admin := util.RandomOwner()
+	user := util.RandomOwner()
+	usd := db.Currencies{Code: util.USD, NumericCode: "840", Exponent: 2, UpdatedAt: time.Now()}
+
+	testCases := []struct {
+		name          string
+		user          string
+		path          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, server *Server)
+	}{
+		{
+			name: "Disable",
+			user: admin,
+			path: "/admin/currencies/USD/disable",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SetCurrencyEnabledParams{Code: util.USD, Enabled: false}
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Eq(arg)).Times(1).Return(usd, nil)
+				store.EXPECT().EnableCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.Currencies
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, util.USD, got.Code)
+				require.False(t, got.Enabled)
+				require.False(t, server.currencies.IsEnabled(util.USD))
+
+				// the validation of the requests stops accepting it
+				body, err := json.Marshal(gin.H{"currency": util.USD})
+				require.NoError(t, err)
+				request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(body))
+				require.NoError(t, err)
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user, time.Minute)
+
+				recorder = httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Enable",
+			user: admin,
+			path: "/admin/currencies/USD/enable",
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := usd
+				enabled.Enabled = true
+				store.EXPECT().EnableCurrencyTx(gomock.Any(), gomock.Eq(util.USD)).Times(1).Return(enabled, nil)
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.True(t, server.currencies.IsEnabled(util.USD))
+			},
+		},
+		{
+			name: "LowercaseCode",
+			user: admin,
+			path: "/admin/currencies/usd/enable",
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := usd
+				enabled.Enabled = true
+				store.EXPECT().EnableCurrencyTx(gomock.Any(), gomock.Eq(util.USD)).Times(1).Return(enabled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.True(t, server.currencies.IsEnabled(util.USD))
+				_, ok := server.currencies.Get("usd")
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "NotFound",
+			user: admin,
+			path: "/admin/currencies/XYZ/enable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableCurrencyTx(gomock.Any(), gomock.Eq("XYZ")).Times(1).Return(db.Currencies{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				_, ok := server.currencies.Get("XYZ")
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "InvalidCode",
+			user: admin,
+			path: "/admin/currencies/US1/enable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			user: user,
+			path: "/admin/currencies/USD/disable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.True(t, server.currencies.IsEnabled(util.USD))
+			},
+		},
+		{
+			name: "InternalError",
+			user: admin,
+			path: "/admin/currencies/USD/disable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetCurrencyEnabled(gomock.Any(), gomock.Any()).Times(1).Return(db.Currencies{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.True(t, server.currencies.IsEnabled(util.USD))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AdminEmails = []string{admin}
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, tc.path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server)
+		})
+	}
+}
+
+func TestListCurrenciesAPI(t *testing.T) {
+	admin := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	server.config.AdminEmails = []string{admin}
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/currencies", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []util.Currency
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, util.DefaultCurrencies, got, fmt.Sprint(got))
+}
+
+func TestValidCurrencyWithoutRegistry(t *testing.T) {
+	registry := currencyRegistry.Load()
+	defer currencyRegistry.Store(registry)
+
+	// before a server is created no currency is valid, instead of panicking
+	currencyRegistry.Store(nil)
+	v := validator.New()
+	v.RegisterValidation("currency", validCurrency)
+	require.Error(t, v.Var(util.USD, "currency"))
+
+	_, err := NewServer(util.Config{}, nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deprecationHeader flags the responses of the page_id pagination, replaced by cursors
+const deprecationHeader = "Deprecation"
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last item of a page, the next page starts right after it.
+// The lists are ordered by (created_at, id), so the id breaks the ties of created_at.
+type pageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+// listResponse is the envelope of the cursor paginated lists,
+// NextCursor is empty on the last page
+type listResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// encodeCursor turns the cursor into an opaque token, signed so clients can't forge positions.
+// The signature covers the request path and its filters, so a cursor only works on the list it came from.
+func (s *Server) encodeCursor(ctx *gin.Context, cursor pageCursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	signature := base64.RawURLEncoding.EncodeToString(s.signCursor(ctx, encoded))
+
+	return encoded + "." + signature, nil
+}
+
+// decodeCursor verifies the token and returns its cursor, an empty token is the start of the list
+func (s *Server) decodeCursor(ctx *gin.Context, token string) (cursor pageCursor, err error) {
+	if token == "" {
+		return
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return cursor, errInvalidCursor
+	}
+
+	gotSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(gotSignature, s.signCursor(ctx, encoded)) {
+		return cursor, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// signCursor signs the encoded cursor along with the list it belongs to, the path and the query
+// without the cursor and the page size. url.Values.Encode sorts the parameters, so their order doesn't matter.
+func (s *Server) signCursor(ctx *gin.Context, encoded string) []byte {
+	filters := ctx.Request.URL.Query()
+	filters.Del("cursor")
+	filters.Del("page_size")
+
+	mac := hmac.New(sha256.New, s.cursorKey)
+	mac.Write([]byte(ctx.Request.URL.Path))
+	mac.Write([]byte{0})
+	mac.Write([]byte(filters.Encode()))
+	mac.Write([]byte{0})
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// deriveCursorKey derives the key of the cursors from the token key, so a signature made for one
+// purpose can never be valid for the other
+func deriveCursorKey(tokenSymmetricKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(tokenSymmetricKey))
+	mac.Write([]byte("page cursor"))
+	return mac.Sum(nil)
+}
+
+// newListResponse builds the page out of items, queried with one more than pageSize
+// to know if there is a next page without an extra query
+func newListResponse[T any](s *Server, ctx *gin.Context, items []T, pageSize int32, cursorOf func(T) pageCursor) (listResponse[T], error) {
+	rsp := listResponse[T]{Items: items}
+	if len(items) <= int(pageSize) {
+		return rsp, nil
+	}
+
+	rsp.Items = items[:pageSize]
+	nextCursor, err := s.encodeCursor(ctx, cursorOf(rsp.Items[pageSize-1]))
+	if err != nil {
+		return rsp, err
+	}
+	rsp.NextCursor = nextCursor
+
+	return rsp, nil
+}
+
+// mapListResponse converts the items of the page into their responses
+func mapListResponse[T, R any](rsp listResponse[T], f func(T) R) listResponse[R] {
+	return listResponse[R]{Items: mapItems(rsp.Items, f), NextCursor: rsp.NextCursor}
+}
+
+// mapItems converts the items of a list into their responses
+func mapItems[T, R any](items []T, f func(T) R) []R {
+	mapped := make([]R, len(items))
+	for i, item := range items {
+		mapped[i] = f(item)
+	}
+	return mapped
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newCursorContext(t *testing.T, path string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	request, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	ctx.Request = request
+	return ctx
+}
+
+func TestCursor(t *testing.T) {
+	server := newTestServer(t, nil)
+	ctx := newCursorContext(t, "/accounts/1/entries")
+
+	cursor := pageCursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        util.RandomInt(1, 1000),
+	}
+
+	token, err := server.encodeCursor(ctx, cursor)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	gotCursor, err := server.decodeCursor(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, cursor, gotCursor)
+
+	// an empty cursor is the start of the list
+	gotCursor, err = server.decodeCursor(ctx, "")
+	require.NoError(t, err)
+	require.Zero(t, gotCursor)
+
+	// tampered token
+	_, err = server.decodeCursor(ctx, "x"+token)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	_, err = server.decodeCursor(ctx, "not-a-cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	// a cursor only works on the list it came from
+	_, err = server.decodeCursor(newCursorContext(t, "/accounts/2/entries"), token)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	// nor with other filters, while the order of the parameters and the page size don't matter
+	filtered := newCursorContext(t, "/accounts/1/entries?direction=incoming&min_amount=10&page_size=5")
+	token, err = server.encodeCursor(filtered, cursor)
+	require.NoError(t, err)
+	_, err = server.decodeCursor(newCursorContext(t, "/accounts/1/entries?page_size=10&min_amount=10&direction=incoming&cursor="+token), token)
+	require.NoError(t, err)
+	_, err = server.decodeCursor(newCursorContext(t, "/accounts/1/entries?direction=incoming&min_amount=1"), token)
+	require.ErrorIs(t, err, errInvalidCursor)
+	_, err = server.decodeCursor(newCursorContext(t, "/accounts/1/entries?direction=incoming"), token)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	// the key isn't the one of the tokens
+	require.NotEqual(t, []byte(server.config.TokenSymmetricKey), server.cursorKey)
+
+	// nor with another server key
+	otherServer := newTestServer(t, nil)
+	_, err = otherServer.decodeCursor(ctx, token)
+	require.ErrorIs(t, err, errInvalidCursor)
+}
+
+func TestListAccountsCursorAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	pageSize := 5
+
+	// one more than the page, so there is a next page
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	accounts := make([]db.Accounts, pageSize+1)
+	for i := range accounts {
+		accounts[i] = mockAccount(owner)
+		accounts[i].ID = int64(i + 1)
+		accounts[i].CreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	listAccounts := func(query url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/accounts?"+query.Encode(), nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, owner, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// first page
+	store.EXPECT().
+		ListAccountsAfter(gomock.Any(), gomock.Eq(db.ListAccountsAfterParams{
+			Owner: owner,
+			Limit: int32(pageSize + 1),
+		})).
+		Times(1).
+		Return(accounts, nil)
+
+	recorder := listAccounts(url.Values{"page_size": {"5"}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get(deprecationHeader))
+
+	var page listResponse[db.Accounts]
+	err := json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Items, pageSize)
+	require.NotEmpty(t, page.NextCursor)
+	nextCursor := page.NextCursor
+
+	// the next page starts after the last account of the first one
+	store.EXPECT().
+		ListAccountsAfter(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListAccountsAfterParams) ([]db.Accounts, error) {
+			require.Equal(t, accounts[pageSize-1].ID, arg.AfterID)
+			require.True(t, createdAt.Equal(arg.AfterCreatedAt))
+			return accounts[pageSize:], nil
+		})
+
+	recorder = listAccounts(url.Values{"page_size": {"5"}, "cursor": {nextCursor}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	page = listResponse[db.Accounts]{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.Empty(t, page.NextCursor)
+
+	// forged cursor
+	recorder = listAccounts(url.Values{"page_size": {"5"}, "cursor": {"forged"}})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// cursor and page_id can't be mixed
+	recorder = listAccounts(url.Values{"page_size": {"5"}, "page_id": {"1"}, "cursor": {nextCursor}})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// page_id still works, flagged as deprecated
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return(accounts[:pageSize], nil)
+
+	recorder = listAccounts(url.Values{"page_size": {"5"}, "page_id": {"1"}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "true", recorder.Header().Get(deprecationHeader))
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+// listEntries lists the entries of an account of the authenticated user, oldest first.
+// Incoming entries are the positive ones and the amount filters apply to the absolute amount.
+func (s *Server) listEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req historyRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	if req.PageId != 0 {
+		ctx.Header(deprecationHeader, "true")
+
+		arg := db.ListEntriesParams{
+			AccountID:   account.ID,
+			Incoming:    req.incoming(),
+			Outgoing:    req.outgoing(),
+			CreatedFrom: req.createdFrom(),
+			CreatedTo:   req.createdTo(),
+			MinAmount:   req.minAmount(),
+			MaxAmount:   req.maxAmount(),
+			Limit:       req.PageSize,
+			Offset:      req.offset(),
+		}
+
+		entries, err := s.store.ListEntries(ctx, arg)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, mapItems(entries, func(entry db.Entries) entryResponse {
+			return s.newEntryResponse(ctx, entry, account.Currency)
+		}))
+		return
+	}
+
+	after, err := s.decodeCursor(ctx, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListEntriesAfterParams{
+		AccountID:      account.ID,
+		Incoming:       req.incoming(),
+		Outgoing:       req.outgoing(),
+		CreatedFrom:    req.createdFrom(),
+		CreatedTo:      req.createdTo(),
+		MinAmount:      req.minAmount(),
+		MaxAmount:      req.maxAmount(),
+		AfterCreatedAt: after.CreatedAt,
+		AfterID:        after.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	entries, err := s.store.ListEntriesAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := newListResponse(s, ctx, entries, req.PageSize, func(entry db.Entries) pageCursor {
+		return pageCursor{CreatedAt: entry.CreatedAt.Time, ID: entry.ID}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapListResponse(rsp, func(entry db.Entries) entryResponse {
+		return s.newEntryResponse(ctx, entry, account.Currency)
+	}))
+}
+
+// entryResponse adds to the entry its amount written for the locale of the request
+type entryResponse struct {
+	db.Entries
+	AmountDisplay string `json:"amount_display"`
+}
+
+// newEntryResponse builds the response of an entry of an account with the currency
+func (s *Server) newEntryResponse(ctx *gin.Context, entry db.Entries, currency string) entryResponse {
+	return entryResponse{
+		Entries:       entry,
+		AmountDisplay: s.display(ctx, entry.Amount, currency),
+	}
+}
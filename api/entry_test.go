@@ -0,0 +1,191 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEntriesAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	account := mockAccount(user1)
+
+	n := 5
+	entries := make([]db.Entries, n)
+	for i := 0; i < n; i++ {
+		entries[i] = db.Entries{
+			ID:        int64(i + 1),
+			AccountID: account.ID,
+			Amount:    util.RandomMoney(),
+		}
+	}
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			user:  user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListEntriesParams{
+					AccountID: account.ID,
+					Incoming:  true,
+					Outgoing:  true,
+					Limit:     5,
+					Offset:    0,
+				}
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotEntries []db.Entries
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotEntries)
+				require.NoError(t, err)
+				require.Equal(t, entries, gotEntries)
+			},
+		},
+		{
+			name: "Filters",
+			query: url.Values{
+				"page_id":    {"2"},
+				"page_size":  {"5"},
+				"direction":  {"outgoing"},
+				"from":       {from.Format(time.RFC3339)},
+				"to":         {to.Format(time.RFC3339)},
+				"min_amount": {"10"},
+				"max_amount": {"100"},
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListEntriesParams{
+					AccountID:   account.ID,
+					Incoming:    false,
+					Outgoing:    true,
+					CreatedFrom: sql.NullTime{Time: from, Valid: true},
+					CreatedTo:   sql.NullTime{Time: to, Valid: true},
+					MinAmount:   sql.NullInt64{Int64: 10, Valid: true},
+					MaxAmount:   sql.NullInt64{Int64: 100, Valid: true},
+					Limit:       5,
+					Offset:      5,
+				}
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Entries{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			user:  user2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDirection",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}, "direction": {"sideways"}},
+			user:  user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDateRange",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+				"from":      {to.Format(time.RFC3339)},
+				"to":        {from.Format(time.RFC3339)},
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidAmountRange",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}, "min_amount": {"100"}, "max_amount": {"10"}},
+			user:  user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			user:  user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entries{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/fx"
+	"github.com/homocode/bank_demo/token"
+)
+
+var errQuoteNotOwned = errors.New("rate quote doesn't belong to the authenticated user")
+
+type createRateQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+}
+
+// createRateQuote locks the current exchange rate of a currency pair for the authenticated user.
+// The quote can be used by one transfer before it expires.
+func (s *Server) createRateQuote(ctx *gin.Context) {
+	var req createRateQuoteRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, err := s.rateProvider.Rate(ctx, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.CreateRateQuoteParams{
+		Owner:        authPayload.Email,
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Rate:         rate,
+		ExpiresAt:    time.Now().Add(s.config.FxQuoteDuration),
+	}
+
+	quote, err := s.store.CreateRateQuote(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+}
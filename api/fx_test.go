@@ -0,0 +1,393 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/fx"
+	"github.com/homocode/bank_demo/token"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRateQuoteAPI(t *testing.T) {
+	user := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.ARS,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateRateQuote(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateRateQuoteParams) (db.RateQuotes, error) {
+						require.Equal(t, user, arg.Owner)
+						require.Equal(t, util.USD, arg.FromCurrency)
+						require.Equal(t, util.ARS, arg.ToCurrency)
+						require.Equal(t, int64(350_500_000), arg.Rate)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, time.Second)
+						return db.RateQuotes{
+							ID:           1,
+							Owner:        arg.Owner,
+							FromCurrency: arg.FromCurrency,
+							ToCurrency:   arg.ToCurrency,
+							Rate:         arg.Rate,
+							ExpiresAt:    arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var quote db.RateQuotes
+				err := json.Unmarshal(recorder.Body.Bytes(), &quote)
+				require.NoError(t, err)
+				require.Equal(t, int64(1), quote.ID)
+				require.Equal(t, int64(350_500_000), quote.Rate)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.ARS,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateRateQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SameCurrency",
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateRateQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			body: gin.H{
+				"from_currency": "XXX",
+				"to_currency":   util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateRateQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "RateNotFound",
+			body: gin.H{
+				"from_currency": util.EUR,
+				"to_currency":   util.ARS,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateRateQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.ARS,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateRateQuote(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateQuotes{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestFxServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/fx/quotes"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestFxTransferAPI(t *testing.T) {
+	amount := int64(10)
+
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := mockAccount(user1)
+	account1.ID = 1
+	account1.Currency = util.USD
+	account1.Balance += amount
+	account2 := mockAccount(user2)
+	account2.ID = 2
+	account2.Currency = util.ARS
+
+	quote := db.RateQuotes{
+		ID:           util.RandomInt(1, 100),
+		Owner:        user1,
+		FromCurrency: util.USD,
+		ToCurrency:   util.ARS,
+		Rate:         350_500_000,
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	otherQuote := quote
+	otherQuote.Owner = user2
+
+	body := gin.H{
+		"fromAccountId": account1.ID,
+		"toAccountId":   account2.ID,
+		"amount":        amount,
+		"currency":      util.USD,
+		"quoteId":       quote.ID,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+					QuoteID:       quote.ID,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteNotFound",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(db.RateQuotes{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteNotOwned",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(otherQuote, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteCurrencyMismatch",
+			body: gin.H{
+				"fromAccountId": account2.ID,
+				"toAccountId":   account1.ID,
+				"amount":        amount,
+				"currency":      util.ARS,
+				"quoteId":       quote.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				ownedAccount := account2
+				ownedAccount.Owner = user1
+				ownedAccount.Balance += amount
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(ownedAccount, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountCurrencyMismatch",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				eurAccount := account2
+				eurAccount.Currency = util.EUR
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(eurAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteExpired",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w: quote %d", db.ErrQuoteExpired, quote.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteUsed",
+			body: body,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetRateQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w: quote %d", db.ErrQuoteUsed, quote.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidQuoteId",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"quoteId":       -1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/transfer"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// newTestFxServer creates a test server quoting only the USD/ARS pair
+func newTestFxServer(t *testing.T, store Store) *Server {
+	server := newTestServer(t, store)
+
+	provider, err := fx.NewStaticProvider(map[string]string{"USD/ARS": "350.5"})
+	require.NoError(t, err)
+	server.rateProvider = provider
+
+	return server
+}
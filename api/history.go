@@ -0,0 +1,53 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	directionIncoming = "incoming"
+	directionOutgoing = "outgoing"
+)
+
+// historyRequest are the query filters of the entries and transfers of an account.
+// Without a direction both are listed, the dates are RFC 3339 and the range is [from, to).
+// Pages go by Cursor, PageId is deprecated as in listAccountsRequest.
+type historyRequest struct {
+	PageId    int32     `form:"page_id" binding:"omitempty,gt=0"`
+	PageSize  int32     `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor    string    `form:"cursor" binding:"excluded_with=PageId"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to" binding:"omitempty,gtfield=From"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,gt=0"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,gt=0,gtefield=MinAmount"`
+}
+
+func (req historyRequest) incoming() bool {
+	return req.Direction != directionOutgoing
+}
+
+func (req historyRequest) outgoing() bool {
+	return req.Direction != directionIncoming
+}
+
+func (req historyRequest) createdFrom() sql.NullTime {
+	return sql.NullTime{Time: req.From, Valid: !req.From.IsZero()}
+}
+
+func (req historyRequest) createdTo() sql.NullTime {
+	return sql.NullTime{Time: req.To, Valid: !req.To.IsZero()}
+}
+
+func (req historyRequest) minAmount() sql.NullInt64 {
+	return sql.NullInt64{Int64: req.MinAmount, Valid: req.MinAmount != 0}
+}
+
+func (req historyRequest) maxAmount() sql.NullInt64 {
+	return sql.NullInt64{Int64: req.MaxAmount, Valid: req.MaxAmount != 0}
+}
+
+func (req historyRequest) offset() int32 {
+	return (req.PageSize * req.PageId) - req.PageSize
+}
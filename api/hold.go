@@ -0,0 +1,208 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+)
+
+var errHoldNotOwned = errors.New("hold doesn't involve an account of the authenticated user")
+
+type authorizeHoldRequest struct {
+	FromAccountId int64  `json:"fromAccountId" binding:"required,min=1"`
+	ToAccountId   int64  `json:"toAccountId" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,amount"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+// authorizeHold reserves funds of an account of the authenticated user for a later transfer
+// to the ToAccount. The hold expires after the configured duration if it isn't captured.
+func (s *Server) authorizeHold(ctx *gin.Context) {
+	var req authorizeHoldRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountId, req.Currency, sender)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	_, valid = s.validAccount(ctx, req.ToAccountId, req.Currency, recipient)
+	if !valid {
+		return
+	}
+
+	hold, err := s.store.AuthorizeTx(ctx, db.AuthorizeTxParams{
+		FromAccountID: req.FromAccountId,
+		ToAccountID:   req.ToAccountId,
+		Amount:        req.Amount,
+		ExpiresAt:     time.Now().Add(s.config.HoldDuration),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type getHoldRequest struct {
+	Id int64 `uri:"id" binding:"required,gt=0"`
+}
+
+// getHold returns a hold to the owner of any of its accounts
+func (s *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := s.involvedHold(ctx, req.Id)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount to transfer, without it the whole hold is captured
+	Amount int64 `json:"amount" binding:"omitempty,amount"`
+}
+
+// captureHold settles a hold with a transfer. Only the owner of the account
+// the funds were reserved for can capture them.
+func (s *Server) captureHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, without it the whole hold is captured
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, err := s.store.GetHold(ctx, uri.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, valid := s.ownedAccount(ctx, hold.ToAccountID)
+	if !valid {
+		return
+	}
+
+	result, err := s.store.CaptureTx(ctx, db.CaptureTxParams{
+		HoldID: hold.ID,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrHoldNotAuthorized) || errors.Is(err, db.ErrHoldExpired) ||
+			errors.Is(err, db.ErrCaptureExceedsHold) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountClosed) || errors.Is(err, db.ErrLimitExceeded) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, captureResponse{
+		Hold:     result.Hold,
+		Transfer: s.newTransferTxResponse(ctx, result.Transfer),
+	})
+}
+
+// captureResponse is the db.CaptureTxResult with the amounts of its transfer written for the locale of the request
+type captureResponse struct {
+	Hold     db.Holds
+	Transfer transferTxResponse
+}
+
+// voidHold releases the funds reserved by a hold. Only the owner of the account the funds were reserved
+// for can give up on them, otherwise the payer could void it once served, and the hold releases them on expiry.
+func (s *Server) voidHold(ctx *gin.Context) {
+	var req getHoldRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, err := s.store.GetHold(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, valid := s.ownedAccount(ctx, hold.ToAccountID)
+	if !valid {
+		return
+	}
+
+	hold, err = s.store.VoidTx(ctx, hold.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrHoldNotAuthorized) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// involvedHold gets the hold when the authenticated user owns any of its accounts,
+// otherwise it writes the error response and returns false
+func (s *Server) involvedHold(ctx *gin.Context, holdId int64) (db.Holds, bool) {
+	hold, err := s.store.GetHold(ctx, holdId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	if !s.ownsAnyAccount(ctx, errHoldNotOwned, hold.FromAccountID, hold.ToAccountID) {
+		return hold, false
+	}
+
+	return hold, true
+}
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeHoldAPI(t *testing.T) {
+	amount := int64(10)
+
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := mockAccount(user1)
+	account1.ID = 1
+	account1.Currency = util.USD
+	account2 := mockAccount(user2)
+	account2.ID = 2
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.AuthorizeTxParams) (db.Holds, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, amount, arg.Amount)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, time.Second)
+						return db.Holds{ID: 1, FromAccountID: arg.FromAccountID, ToAccountID: arg.ToAccountID, Amount: arg.Amount}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var hold db.Holds
+				err := json.Unmarshal(recorder.Body.Bytes(), &hold)
+				require.NoError(t, err)
+				require.Equal(t, amount, hold.Amount)
+			},
+		},
+		{
+			name: "AccountNotOwned",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			user: user2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.EUR,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        0,
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Holds{}, fmt.Errorf("%w: account %d", db.ErrInsufficientFunds, account1.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/holds", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCaptureHoldAPI(t *testing.T) {
+	customer := util.RandomOwner()
+	merchant := util.RandomOwner()
+
+	account1 := mockAccount(customer)
+	account1.ID = 1
+	account2 := mockAccount(merchant)
+	account2.ID = 2
+
+	hold := db.Holds{
+		ID:            util.RandomInt(1, 100),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Status:        db.HoldAuthorized,
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Full",
+			body: "",
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CaptureTxParams{HoldID: hold.ID}
+				store.EXPECT().CaptureTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Partial",
+			body: `{"amount": 40}`,
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CaptureTxParams{HoldID: hold.ID, Amount: 40}
+				store.EXPECT().CaptureTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CustomerCantCapture",
+			body: "",
+			user: customer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "HoldNotFound",
+			body: "",
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Holds{}, sql.ErrNoRows)
+				store.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: `{"amount": -1}`,
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Expired",
+			body: "",
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CaptureTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureTxResult{}, fmt.Errorf("%w: hold %d", db.ErrHoldExpired, hold.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ExceedsHold",
+			body: `{"amount": 500}`,
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CaptureTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureTxResult{}, fmt.Errorf("%w: hold %d", db.ErrCaptureExceedsHold, hold.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/holds/%d/capture", hold.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVoidHoldAPI(t *testing.T) {
+	customer := util.RandomOwner()
+	merchant := util.RandomOwner()
+
+	account1 := mockAccount(customer)
+	account1.ID = 1
+	account2 := mockAccount(merchant)
+	account2.ID = 2
+
+	hold := db.Holds{
+		ID:            util.RandomInt(1, 100),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Status:        db.HoldAuthorized,
+	}
+
+	testCases := []struct {
+		name          string
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "ByMerchant",
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().VoidTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// the payer can't take back an authorization once served, it's released when it expires
+			name: "ByCustomer",
+			user: customer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().VoidTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotInvolved",
+			user: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().VoidTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyCaptured",
+			user: merchant,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					VoidTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.Holds{}, fmt.Errorf("%w: hold %d is captured", db.ErrHoldNotAuthorized, hold.ID))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/holds/%d/void", hold.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyParams builds the params to make the request idempotent, ok is false when the
+// request has no Idempotency-Key header. The fingerprint is the hash of the bound request,
+// so it doesn't depend on the formatting of the JSON body.
+func (s *Server) idempotencyParams(ctx *gin.Context, owner string, req interface{}) (idem db.IdempotencyParams, ok bool, err error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		err = fmt.Errorf("%s header must have at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		return
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	hash := sha256.Sum256(payload)
+
+	idem = db.IdempotencyParams{
+		Owner:       owner,
+		Key:         key,
+		RequestPath: ctx.FullPath(),
+		RequestHash: hex.EncodeToString(hash[:]),
+		Retention:   s.config.IdempotencyKeyRetention,
+	}
+
+	return idem, true, nil
+}
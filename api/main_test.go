@@ -3,10 +3,28 @@ package api
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
 )
 
+func newTestServer(t *testing.T, store Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		FxQuoteDuration:      time.Minute,
+		HoldDuration:         time.Minute,
+	}
+
+	server, err := NewServer(config, store, util.NewCurrencyRegistry(util.DefaultCurrencies))
+	require.NoError(t, err)
+
+	return server
+}
+
 func TestMain(m *testing.M) {
 	// This is to make less verbose the console output from gin each time a the server runs for a test.
 	// It`s to have a cleaner view of the test logs on the terminal.
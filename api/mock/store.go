@@ -7,8 +7,10 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	db "github.com/homocode/bank_demo/db/sqlc"
 )
 
@@ -50,6 +52,111 @@ func (mr *MockStoreMockRecorder) AddAmountToAccountBalance(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAmountToAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAmountToAccountBalance), arg0, arg1)
 }
 
+// AuthorizeTx mocks base method.
+func (m *MockStore) AuthorizeTx(arg0 context.Context, arg1 db.AuthorizeTxParams) (db.Holds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Holds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorizeTx indicates an expected call of AuthorizeTx.
+func (mr *MockStoreMockRecorder) AuthorizeTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeTx", reflect.TypeOf((*MockStore)(nil).AuthorizeTx), arg0, arg1)
+}
+
+// BalanceHistory mocks base method.
+func (m *MockStore) BalanceHistory(arg0 context.Context, arg1 int64, arg2, arg3 time.Time) ([]db.DailyBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalanceHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]db.DailyBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BalanceHistory indicates an expected call of BalanceHistory.
+func (mr *MockStoreMockRecorder) BalanceHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceHistory", reflect.TypeOf((*MockStore)(nil).BalanceHistory), arg0, arg1, arg2, arg3)
+}
+
+// BatchTransferTx mocks base method.
+func (m *MockStore) BatchTransferTx(arg0 context.Context, arg1 []db.TransferTxParams) ([]db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransferTx", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransferTx indicates an expected call of BatchTransferTx.
+func (mr *MockStoreMockRecorder) BatchTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransferTx", reflect.TypeOf((*MockStore)(nil).BatchTransferTx), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) (db.Sessions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Sessions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// CaptureTx mocks base method.
+func (m *MockStore) CaptureTx(arg0 context.Context, arg1 db.CaptureTxParams) (db.CaptureTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CaptureTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureTx indicates an expected call of CaptureTx.
+func (mr *MockStoreMockRecorder) CaptureTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureTx", reflect.TypeOf((*MockStore)(nil).CaptureTx), arg0, arg1)
+}
+
+// ChangeAccountStatusTx mocks base method.
+func (m *MockStore) ChangeAccountStatusTx(arg0 context.Context, arg1 db.ChangeAccountStatusTxParams) (db.Accounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatusTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Accounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatusTx indicates an expected call of ChangeAccountStatusTx.
+func (mr *MockStoreMockRecorder) ChangeAccountStatusTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatusTx", reflect.TypeOf((*MockStore)(nil).ChangeAccountStatusTx), arg0, arg1)
+}
+
+// CloseAccountTx mocks base method.
+func (m *MockStore) CloseAccountTx(arg0 context.Context, arg1 db.CloseAccountTxParams) (db.CloseAccountTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CloseAccountTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseAccountTx indicates an expected call of CloseAccountTx.
+func (mr *MockStoreMockRecorder) CloseAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAccountTx", reflect.TypeOf((*MockStore)(nil).CloseAccountTx), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Accounts, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +172,22 @@ func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
 }
 
+// CreateAccountIdempotent mocks base method.
+func (m *MockStore) CreateAccountIdempotent(arg0 context.Context, arg1 db.IdempotencyParams, arg2 db.CreateAccountParams) (db.Accounts, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountIdempotent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(db.Accounts)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAccountIdempotent indicates an expected call of CreateAccountIdempotent.
+func (mr *MockStoreMockRecorder) CreateAccountIdempotent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountIdempotent", reflect.TypeOf((*MockStore)(nil).CreateAccountIdempotent), arg0, arg1, arg2)
+}
+
 // CreateEntry mocks base method.
 func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entries, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +203,51 @@ func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
 }
 
+// CreateRateQuote mocks base method.
+func (m *MockStore) CreateRateQuote(arg0 context.Context, arg1 db.CreateRateQuoteParams) (db.RateQuotes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRateQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.RateQuotes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRateQuote indicates an expected call of CreateRateQuote.
+func (mr *MockStoreMockRecorder) CreateRateQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRateQuote", reflect.TypeOf((*MockStore)(nil).CreateRateQuote), arg0, arg1)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(arg0 context.Context, arg1 db.CreateScheduledTransferParams) (db.ScheduledTransfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Sessions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Sessions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateTransfer mocks base method.
 func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfers, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +263,51 @@ func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
 }
 
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// DepositTx mocks base method.
+func (m *MockStore) DepositTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockStoreMockRecorder) DepositTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockStore)(nil).DepositTx), arg0, arg1)
+}
+
+// EnableCurrencyTx mocks base method.
+func (m *MockStore) EnableCurrencyTx(arg0 context.Context, arg1 string) (db.Currencies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableCurrencyTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Currencies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableCurrencyTx indicates an expected call of EnableCurrencyTx.
+func (mr *MockStoreMockRecorder) EnableCurrencyTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableCurrencyTx", reflect.TypeOf((*MockStore)(nil).EnableCurrencyTx), arg0, arg1)
+}
+
 // GetAccount mocks base method.
 func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Accounts, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +323,36 @@ func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
 }
 
+// GetAccountByNumber mocks base method.
+func (m *MockStore) GetAccountByNumber(arg0 context.Context, arg1 string) (db.Accounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByNumber", arg0, arg1)
+	ret0, _ := ret[0].(db.Accounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByNumber indicates an expected call of GetAccountByNumber.
+func (mr *MockStoreMockRecorder) GetAccountByNumber(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByNumber", reflect.TypeOf((*MockStore)(nil).GetAccountByNumber), arg0, arg1)
+}
+
+// GetBalanceAt mocks base method.
+func (m *MockStore) GetBalanceAt(arg0 context.Context, arg1 db.GetBalanceAtParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAt", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAt indicates an expected call of GetBalanceAt.
+func (mr *MockStoreMockRecorder) GetBalanceAt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAt", reflect.TypeOf((*MockStore)(nil).GetBalanceAt), arg0, arg1)
+}
+
 // GetEntry mocks base method.
 func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entries, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +368,81 @@ func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
 }
 
+// GetHold mocks base method.
+func (m *MockStore) GetHold(arg0 context.Context, arg1 int64) (db.Holds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Holds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockStoreMockRecorder) GetHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockStore)(nil).GetHold), arg0, arg1)
+}
+
+// GetLatestReconciliationReport mocks base method.
+func (m *MockStore) GetLatestReconciliationReport(arg0 context.Context) (db.ReconciliationReports, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestReconciliationReport", arg0)
+	ret0, _ := ret[0].(db.ReconciliationReports)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestReconciliationReport indicates an expected call of GetLatestReconciliationReport.
+func (mr *MockStoreMockRecorder) GetLatestReconciliationReport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestReconciliationReport", reflect.TypeOf((*MockStore)(nil).GetLatestReconciliationReport), arg0)
+}
+
+// GetRateQuote mocks base method.
+func (m *MockStore) GetRateQuote(arg0 context.Context, arg1 int64) (db.RateQuotes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.RateQuotes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRateQuote indicates an expected call of GetRateQuote.
+func (mr *MockStoreMockRecorder) GetRateQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateQuote", reflect.TypeOf((*MockStore)(nil).GetRateQuote), arg0, arg1)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(arg0 context.Context, arg1 int64) (db.ScheduledTransfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Sessions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Sessions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
 // GetTransfer mocks base method.
 func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfers, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +458,21 @@ func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
 }
 
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
 // ListAccounts mocks base method.
 func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Accounts, error) {
 	m.ctrl.T.Helper()
@@ -155,6 +488,21 @@ func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
 }
 
+// ListAccountsAfter mocks base method.
+func (m *MockStore) ListAccountsAfter(arg0 context.Context, arg1 db.ListAccountsAfterParams) ([]db.Accounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Accounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsAfter indicates an expected call of ListAccountsAfter.
+func (mr *MockStoreMockRecorder) ListAccountsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsAfter", reflect.TypeOf((*MockStore)(nil).ListAccountsAfter), arg0, arg1)
+}
+
 // ListEntries mocks base method.
 func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entries, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +518,66 @@ func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
 }
 
+// ListEntriesAfter mocks base method.
+func (m *MockStore) ListEntriesAfter(arg0 context.Context, arg1 db.ListEntriesAfterParams) ([]db.Entries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntriesAfter indicates an expected call of ListEntriesAfter.
+func (mr *MockStoreMockRecorder) ListEntriesAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListEntriesAfter), arg0, arg1)
+}
+
+// ListReconciliationDiscrepancies mocks base method.
+func (m *MockStore) ListReconciliationDiscrepancies(arg0 context.Context, arg1 int64) ([]db.ReconciliationDiscrepancies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReconciliationDiscrepancies", arg0, arg1)
+	ret0, _ := ret[0].([]db.ReconciliationDiscrepancies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReconciliationDiscrepancies indicates an expected call of ListReconciliationDiscrepancies.
+func (mr *MockStoreMockRecorder) ListReconciliationDiscrepancies(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReconciliationDiscrepancies", reflect.TypeOf((*MockStore)(nil).ListReconciliationDiscrepancies), arg0, arg1)
+}
+
+// ListScheduledTransferRuns mocks base method.
+func (m *MockStore) ListScheduledTransferRuns(arg0 context.Context, arg1 db.ListScheduledTransferRunsParams) ([]db.ScheduledTransferRuns, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransferRuns", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScheduledTransferRuns)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransferRuns indicates an expected call of ListScheduledTransferRuns.
+func (mr *MockStoreMockRecorder) ListScheduledTransferRuns(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransferRuns", reflect.TypeOf((*MockStore)(nil).ListScheduledTransferRuns), arg0, arg1)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(arg0 context.Context, arg1 db.ListScheduledTransfersParams) ([]db.ScheduledTransfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScheduledTransfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), arg0, arg1)
+}
+
 // ListTransfers mocks base method.
 func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfers, error) {
 	m.ctrl.T.Helper()
@@ -185,6 +593,110 @@ func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
 }
 
+// ListTransfersAfter mocks base method.
+func (m *MockStore) ListTransfersAfter(arg0 context.Context, arg1 db.ListTransfersAfterParams) ([]db.Transfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfersAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfersAfter indicates an expected call of ListTransfersAfter.
+func (mr *MockStoreMockRecorder) ListTransfersAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersAfter", reflect.TypeOf((*MockStore)(nil).ListTransfersAfter), arg0, arg1)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(arg0 context.Context, arg1 db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), arg0, arg1)
+}
+
+// SetCurrencyEnabled mocks base method.
+func (m *MockStore) SetCurrencyEnabled(arg0 context.Context, arg1 db.SetCurrencyEnabledParams) (db.Currencies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCurrencyEnabled", arg0, arg1)
+	ret0, _ := ret[0].(db.Currencies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCurrencyEnabled indicates an expected call of SetCurrencyEnabled.
+func (mr *MockStoreMockRecorder) SetCurrencyEnabled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCurrencyEnabled", reflect.TypeOf((*MockStore)(nil).SetCurrencyEnabled), arg0, arg1)
+}
+
+// StatementTx mocks base method.
+func (m *MockStore) StatementTx(arg0 context.Context, arg1 db.StatementTxParams, arg2 db.StatementWriter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatementTx", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StatementTx indicates an expected call of StatementTx.
+func (mr *MockStoreMockRecorder) StatementTx(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatementTx", reflect.TypeOf((*MockStore)(nil).StatementTx), arg0, arg1, arg2)
+}
+
+// SumActiveHolds mocks base method.
+func (m *MockStore) SumActiveHolds(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumActiveHolds", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumActiveHolds indicates an expected call of SumActiveHolds.
+func (mr *MockStoreMockRecorder) SumActiveHolds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumActiveHolds", reflect.TypeOf((*MockStore)(nil).SumActiveHolds), arg0, arg1)
+}
+
+// TransferAllowances mocks base method.
+func (m *MockStore) TransferAllowances(arg0 context.Context, arg1 db.Accounts) ([]db.TransferAllowance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferAllowances", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferAllowance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferAllowances indicates an expected call of TransferAllowances.
+func (mr *MockStoreMockRecorder) TransferAllowances(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferAllowances", reflect.TypeOf((*MockStore)(nil).TransferAllowances), arg0, arg1)
+}
+
+// TransferFee mocks base method.
+func (m *MockStore) TransferFee(arg0 context.Context, arg1 db.Accounts, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferFee", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferFee indicates an expected call of TransferFee.
+func (mr *MockStoreMockRecorder) TransferFee(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferFee", reflect.TypeOf((*MockStore)(nil).TransferFee), arg0, arg1, arg2)
+}
+
 // TransferTx mocks base method.
 func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
 	m.ctrl.T.Helper()
@@ -199,3 +711,64 @@ func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
 }
+
+// TransferTxIdempotent mocks base method.
+func (m *MockStore) TransferTxIdempotent(arg0 context.Context, arg1 db.IdempotencyParams, arg2 db.TransferTxParams) (db.TransferTxResult, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferTxIdempotent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TransferTxIdempotent indicates an expected call of TransferTxIdempotent.
+func (mr *MockStoreMockRecorder) TransferTxIdempotent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTxIdempotent", reflect.TypeOf((*MockStore)(nil).TransferTxIdempotent), arg0, arg1, arg2)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockStore) UpdateScheduledTransfer(arg0 context.Context, arg1 db.UpdateScheduledTransferParams) (db.ScheduledTransfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockStoreMockRecorder) UpdateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).UpdateScheduledTransfer), arg0, arg1)
+}
+
+// VoidTx mocks base method.
+func (m *MockStore) VoidTx(arg0 context.Context, arg1 int64) (db.Holds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Holds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidTx indicates an expected call of VoidTx.
+func (mr *MockStoreMockRecorder) VoidTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidTx", reflect.TypeOf((*MockStore)(nil).VoidTx), arg0, arg1)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockStore) WithdrawTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockStoreMockRecorder) WithdrawTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockStore)(nil).WithdrawTx), arg0, arg1)
+}
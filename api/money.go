@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/homocode/bank_demo/util"
+)
+
+// acceptLanguageHeader picks the locale of the display strings of the amounts
+const acceptLanguageHeader = "Accept-Language"
+
+// currency is the currency of the registry with the code. One added to the table after the last
+// refresh of the registry is taken to have 2 decimals, the exponent of all the seeded ones.
+func (s *Server) currency(code string) util.Currency {
+	if currency, ok := s.currencies.Get(code); ok {
+		return currency
+	}
+
+	return util.Currency{Code: code, Exponent: 2}
+}
+
+// display writes the amount in minor units of the currency for the locale of the request
+func (s *Server) display(ctx *gin.Context, amount int64, currency string) string {
+	locale := util.LookupLocale(ctx.GetHeader(acceptLanguageHeader))
+	return util.NewMoney(amount, s.currency(currency)).Format(locale)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/homocode/bank_demo/pain"
+	"github.com/homocode/bank_demo/token"
+)
+
+// maxPaymentFileSize is the largest pain.001 accepted, about 10000 transfers
+const maxPaymentFileSize = 10 << 20
+
+// importPaymentFile books the transfers of the pain.001 in the body out of accounts of the authenticated
+// user and answers with a pain.002 with the status of each one. Transfers that are rejected don't
+// stop the rest, an invalid file is rejected before booking any.
+func (s *Server) importPaymentFile(ctx *gin.Context) {
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPaymentFileSize)
+	initiation, err := pain.Parse(body, s.currencies)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	report := pain.NewImporter(s.config, s.store).Import(ctx, initiation, authPayload.Email)
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+}
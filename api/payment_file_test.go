@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func paymentFile(from db.Accounts, to db.Accounts, amount string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2023-03-31T09:00:00</CreDtTm><NbOfTxs>1</NbOfTxs></GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`, from.ID, from.Currency, amount, to.ID)
+}
+
+func TestImportPaymentFileAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	account1 := mockAccount(user1)
+	account2 := mockAccount(user2)
+	account2.Currency = account1.Currency
+
+	testCases := []struct {
+		name          string
+		user          string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user1,
+			body: paymentFile(account1, account2, "1.50"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 150}
+				store.EXPECT().TransferTxIdempotent(gomock.Any(), gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.TransferTxResult{Transfer: db.Transfers{ID: 11}}, false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "application/xml")
+
+				body := recorder.Body.String()
+				require.Contains(t, body, "<GrpSts>ACSC</GrpSts>")
+				require.Contains(t, body, "<AcctSvcrRef>11</AcctSvcrRef>")
+			},
+		},
+		{
+			name: "InvalidFile",
+			user: user1,
+			body: paymentFile(account1, account2, "1.5.0"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxIdempotent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotOwned",
+			user: user2,
+			body: paymentFile(account1, account2, "1.50"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTxIdempotent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				body := recorder.Body.String()
+				require.Contains(t, body, "<GrpSts>RJCT</GrpSts>")
+				require.Contains(t, body, "<Cd>AG01</Cd>")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/pain001", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/xml")
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
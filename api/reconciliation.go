@@ -0,0 +1,39 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+type reconciliationResponse struct {
+	Report        db.ReconciliationReports         `json:"report"`
+	Discrepancies []db.ReconciliationDiscrepancies `json:"discrepancies"`
+}
+
+// getLatestReconciliation answers with the last report of the reconciliation of the ledger
+// and its discrepancies
+func (s *Server) getLatestReconciliation(ctx *gin.Context) {
+	report, err := s.store.GetLatestReconciliationReport(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	discrepancies, err := s.store.ListReconciliationDiscrepancies(ctx, report.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reconciliationResponse{
+		Report:        report,
+		Discrepancies: discrepancies,
+	})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatestReconciliationAPI(t *testing.T) {
+	admin := util.RandomOwner()
+	user := util.RandomOwner()
+
+	report := db.ReconciliationReports{
+		ID:               util.RandomInt(1, 1000),
+		AccountsChecked:  10,
+		TransfersChecked: 20,
+		Discrepancies:    1,
+		CreatedAt:        time.Now(),
+	}
+	discrepancies := []db.ReconciliationDiscrepancies{
+		{
+			ID:        1,
+			ReportID:  report.ID,
+			Kind:      db.DiscrepancyAccountBalance,
+			AccountID: sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+			Currency:  sql.NullString{String: util.RandomCurrency(), Valid: true},
+			Expected:  100,
+			Actual:    90,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLatestReconciliationReport(gomock.Any()).Times(1).Return(report, nil)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Eq(report.ID)).Times(1).Return(discrepancies, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got reconciliationResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, report.ID, got.Report.ID)
+				require.Equal(t, discrepancies, got.Discrepancies)
+			},
+		},
+		{
+			name: "NotAdmin",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLatestReconciliationReport(gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoReport",
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLatestReconciliationReport(gomock.Any()).Times(1).Return(db.ReconciliationReports{}, sql.ErrNoRows)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			user: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLatestReconciliationReport(gomock.Any()).Times(1).Return(report, nil)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AdminEmails = []string{admin}
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/reconciliation/latest", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
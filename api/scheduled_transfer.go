@@ -0,0 +1,244 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+)
+
+var (
+	errScheduledTransferNotActive = errors.New("scheduled transfer is not active")
+	errStartsAtPast               = errors.New("startsAt must be in the future")
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountId int64     `json:"fromAccountId" binding:"required,min=1"`
+	ToAccountId   int64     `json:"toAccountId" binding:"required,min=1"`
+	Amount        int64     `json:"amount" binding:"required,amount"`
+	Currency      string    `json:"currency" binding:"required,currency"`
+	StartsAt      time.Time `json:"startsAt" binding:"required"`
+	// IntervalUnit makes the transfer recurring, without it the transfer runs once
+	IntervalUnit  string `json:"intervalUnit" binding:"omitempty,oneof=day week month"`
+	IntervalCount int32  `json:"intervalCount" binding:"omitempty,min=1"`
+}
+
+// createScheduledTransfer schedules a transfer from an account of the authenticated user,
+// the worker books it at StartsAt and then every IntervalCount IntervalUnit
+func (s *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.StartsAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errStartsAtPast))
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountId, req.Currency, sender)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	_, valid = s.validAccount(ctx, req.ToAccountId, req.Currency, recipient)
+	if !valid {
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		FromAccountID: req.FromAccountId,
+		ToAccountID:   req.ToAccountId,
+		Amount:        req.Amount,
+		IntervalUnit:  sql.NullString{String: req.IntervalUnit, Valid: req.IntervalUnit != ""},
+		IntervalCount: req.IntervalCount,
+		StartsAt:      req.StartsAt,
+	}
+	if arg.IntervalCount == 0 {
+		arg.IntervalCount = 1
+	}
+
+	scheduled, err := s.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type getScheduledTransferRequest struct {
+	Id int64 `uri:"id" binding:"required,gt=0"`
+}
+
+func (s *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := s.ownedScheduledTransfer(ctx, req.Id)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledRequest struct {
+	PageId   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listScheduledTransfers lists the transfers scheduled from an account of the authenticated user
+func (s *Server) listScheduledTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listScheduledRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	scheduled, err := s.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		FromAccountID: account.ID,
+		Limit:         req.PageSize,
+		Offset:        (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount int64 `json:"amount" binding:"required,amount"`
+}
+
+// updateScheduledTransfer changes the amount of the next runs of an active scheduled transfer
+func (s *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	s.changeScheduledTransfer(ctx, uri.Id, db.UpdateScheduledTransferParams{
+		Amount: sql.NullInt64{Int64: req.Amount, Valid: true},
+	})
+}
+
+// cancelScheduledTransfer stops an active scheduled transfer, its past runs are kept
+func (s *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	s.changeScheduledTransfer(ctx, uri.Id, db.UpdateScheduledTransferParams{
+		Status: sql.NullString{String: db.ScheduledTransferCancelled, Valid: true},
+	})
+}
+
+// changeScheduledTransfer applies arg to the scheduled transfer when it belongs to the authenticated
+// user. Only active transfers change, the update is skipped if the worker finished it meanwhile.
+func (s *Server) changeScheduledTransfer(ctx *gin.Context, id int64, arg db.UpdateScheduledTransferParams) {
+	scheduled, valid := s.ownedScheduledTransfer(ctx, id)
+	if !valid {
+		return
+	}
+
+	arg.ID = scheduled.ID
+	scheduled, err := s.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errScheduledTransferNotActive))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// listScheduledTransferRuns lists the attempts to book a scheduled transfer, oldest first
+func (s *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listScheduledRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := s.ownedScheduledTransfer(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	runs, err := s.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// ownedScheduledTransfer gets the scheduled transfer when its FromAccount belongs to the
+// authenticated user, otherwise it writes the error response and returns false
+func (s *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfers, bool) {
+	scheduled, err := s.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduled, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduled, false
+	}
+
+	_, valid := s.ownedAccount(ctx, scheduled.FromAccountID)
+	return scheduled, valid
+}
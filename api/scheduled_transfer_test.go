@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	amount := int64(10)
+	startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := mockAccount(user1)
+	account1.ID = 1
+	account1.Currency = util.USD
+	account2 := mockAccount(user2)
+	account2.ID = 2
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Monthly",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"startsAt":      startsAt,
+				"intervalUnit":  db.IntervalMonth,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CreateScheduledTransferParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					IntervalUnit:  sql.NullString{String: db.IntervalMonth, Valid: true},
+					IntervalCount: 1,
+					StartsAt:      startsAt,
+				}
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Once",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"startsAt":      startsAt,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CreateScheduledTransferParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					IntervalCount: 1,
+					StartsAt:      startsAt,
+				}
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "StartsInThePast",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"startsAt":      time.Now().Add(-time.Hour),
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidIntervalUnit",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"startsAt":      startsAt,
+				"intervalUnit":  "year",
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotOwned",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+				"startsAt":      startsAt,
+			},
+			user: user2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCancelScheduledTransferAPI(t *testing.T) {
+	user := util.RandomOwner()
+	account := mockAccount(user)
+
+	scheduled := db.ScheduledTransfers{
+		ID:            util.RandomInt(1, 100),
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID + 1,
+		Amount:        10,
+		Status:        db.ScheduledTransferActive,
+	}
+
+	testCases := []struct {
+		name          string
+		user          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.UpdateScheduledTransferParams{
+					ID:     scheduled.ID,
+					Status: sql.NullString{String: db.ScheduledTransferCancelled, Valid: true},
+				}
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotActive",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ScheduledTransfers{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwned",
+			user: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(db.ScheduledTransfers{}, sql.ErrNoRows)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -2,25 +2,54 @@ package api
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/fx"
+	"github.com/homocode/bank_demo/token"
+	"github.com/homocode/bank_demo/util"
 )
 
 type queries interface {
 	AddAmountToAccountBalance(ctx context.Context, arg db.AddAmountToAccountBalanceParams) (db.Accounts, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (db.Sessions, error)
 	CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Accounts, error)
 	CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entries, error)
+	CreateRateQuote(ctx context.Context, arg db.CreateRateQuoteParams) (db.RateQuotes, error)
+	CreateScheduledTransfer(ctx context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfers, error)
+	CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Sessions, error)
 	CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfers, error)
+	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.Users, error)
 	GetAccount(ctx context.Context, id int64) (db.Accounts, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (db.Accounts, error)
+	GetBalanceAt(ctx context.Context, arg db.GetBalanceAtParams) (int64, error)
 	GetEntry(ctx context.Context, id int64) (db.Entries, error)
+	GetHold(ctx context.Context, id int64) (db.Holds, error)
+	GetLatestReconciliationReport(ctx context.Context) (db.ReconciliationReports, error)
+	GetRateQuote(ctx context.Context, id int64) (db.RateQuotes, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (db.ScheduledTransfers, error)
+	GetSession(ctx context.Context, id uuid.UUID) (db.Sessions, error)
 	GetTransfer(ctx context.Context, id int64) (db.Transfers, error)
+	GetUser(ctx context.Context, email string) (db.Users, error)
 	ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Accounts, error)
+	ListAccountsAfter(ctx context.Context, arg db.ListAccountsAfterParams) ([]db.Accounts, error)
 	ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entries, error)
+	ListEntriesAfter(ctx context.Context, arg db.ListEntriesAfterParams) ([]db.Entries, error)
+	ListReconciliationDiscrepancies(ctx context.Context, reportID int64) ([]db.ReconciliationDiscrepancies, error)
+	ListScheduledTransferRuns(ctx context.Context, arg db.ListScheduledTransferRunsParams) ([]db.ScheduledTransferRuns, error)
+	ListScheduledTransfers(ctx context.Context, arg db.ListScheduledTransfersParams) ([]db.ScheduledTransfers, error)
 	ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfers, error)
+	ListTransfersAfter(ctx context.Context, arg db.ListTransfersAfterParams) ([]db.Transfers, error)
+	SetCurrencyEnabled(ctx context.Context, arg db.SetCurrencyEnabledParams) (db.Currencies, error)
+	SumActiveHolds(ctx context.Context, accountID int64) (int64, error)
+	UpdateScheduledTransfer(ctx context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfers, error)
 }
 
 var _ queries = (*db.Queries)(nil)
@@ -28,37 +57,152 @@ var _ queries = (*db.Queries)(nil)
 type Store interface {
 	queries
 	TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error)
+	TransferTxIdempotent(ctx context.Context, idem db.IdempotencyParams, arg db.TransferTxParams) (db.TransferTxResult, bool, error)
+	CreateAccountIdempotent(ctx context.Context, idem db.IdempotencyParams, arg db.CreateAccountParams) (db.Accounts, bool, error)
+	ReverseTransferTx(ctx context.Context, arg db.ReverseTransferTxParams) (db.TransferTxResult, error)
+	DepositTx(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)
+	AuthorizeTx(ctx context.Context, arg db.AuthorizeTxParams) (db.Holds, error)
+	CaptureTx(ctx context.Context, arg db.CaptureTxParams) (db.CaptureTxResult, error)
+	VoidTx(ctx context.Context, holdID int64) (db.Holds, error)
+	ChangeAccountStatusTx(ctx context.Context, arg db.ChangeAccountStatusTxParams) (db.Accounts, error)
+	CloseAccountTx(ctx context.Context, arg db.CloseAccountTxParams) (db.CloseAccountTxResult, error)
+	TransferAllowances(ctx context.Context, account db.Accounts) ([]db.TransferAllowance, error)
+	TransferFee(ctx context.Context, account db.Accounts, amount int64) (int64, error)
+	BatchTransferTx(ctx context.Context, transfers []db.TransferTxParams) ([]db.TransferTxResult, error)
+	BalanceHistory(ctx context.Context, accountID int64, fromDay time.Time, toDay time.Time) ([]db.DailyBalance, error)
+	StatementTx(ctx context.Context, arg db.StatementTxParams, w db.StatementWriter) error
+	EnableCurrencyTx(ctx context.Context, code string) (db.Currencies, error)
 }
 
 type Server struct {
-	store  Store
-	router *gin.Engine
+	config       util.Config
+	store        Store
+	tokenMaker   token.Maker
+	rateProvider fx.ExchangeRateProvider
+	currencies   *util.CurrencyRegistry
+	// cursorKey signs the page cursors, see deriveCursorKey
+	cursorKey []byte
+	router    *gin.Engine
 }
 
-// Creates a new HTTP server and setup routing
-func NewServer(store Store) *Server {
-	server := &Server{store: store}
+// Creates a new HTTP server and setup routing, the currency validator and the admin endpoints use currencies
+func NewServer(config util.Config, store Store, currencies *util.CurrencyRegistry) (*Server, error) {
+	if currencies == nil {
+		return nil, errors.New("cannot create server without a currency registry")
+	}
+
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	rateProvider, err := newRateProvider(config.FxRatesFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create exchange rate provider: %w", err)
+	}
+
+	server := &Server{
+		config:       config,
+		store:        store,
+		tokenMaker:   tokenMaker,
+		rateProvider: rateProvider,
+		currencies:   currencies,
+		cursorKey:    deriveCursorKey(config.TokenSymmetricKey),
+	}
+	currencyRegistry.Store(currencies)
 	router := gin.Default()
 
 	// Engine returns
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_number", validAccountNumber)
+		v.RegisterValidation("amount", validAmount)
 	}
 
 	const (
-		pathAccounts = "/accounts"
-		pathTransfer = "/transfer"
+		pathAccounts  = "/accounts"
+		pathTransfer  = "/transfer"
+		pathTransfers = "/transfers"
+		pathUsers     = "/users"
+		pathTokens    = "/tokens"
+		pathSessions  = "/sessions"
+		pathFx        = "/fx"
+		pathHolds     = "/holds"
+		pathScheduled = "/scheduled_transfers"
+		pathAdmin     = "/admin"
 	)
 
-	router.POST(fmt.Sprintf("%v", pathAccounts), server.createAccount)
-	router.GET(fmt.Sprintf("%v/:id", pathAccounts), server.getAccount)
-	router.GET(fmt.Sprintf("%v", pathAccounts), server.listAccounts)
+	router.POST(fmt.Sprintf("%v", pathUsers), server.createUser)
+	router.POST(fmt.Sprintf("%v/login", pathUsers), server.loginUser)
+	router.POST(fmt.Sprintf("%v/renew_access", pathTokens), server.renewAccessToken)
+
+	// Routes that require a valid access token
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+
+	authRoutes.POST(fmt.Sprintf("%v", pathAccounts), server.createAccount)
+	authRoutes.GET(fmt.Sprintf("%v/:id", pathAccounts), server.getAccount)
+	authRoutes.GET(fmt.Sprintf("%v", pathAccounts), server.listAccounts)
+	// cash is handed over at a teller, the admins, so only they can book it
+	authRoutes.POST(fmt.Sprintf("%v/:id/deposits", pathAccounts), server.adminMiddleware(), server.depositToAccount)
+	authRoutes.POST(fmt.Sprintf("%v/:id/withdrawals", pathAccounts), server.adminMiddleware(), server.withdrawFromAccount)
+	authRoutes.GET(fmt.Sprintf("%v/:id/entries", pathAccounts), server.listEntries)
+	authRoutes.GET(fmt.Sprintf("%v/:id/transfers", pathAccounts), server.listTransfers)
+	authRoutes.GET(fmt.Sprintf("%v/:id/scheduled_transfers", pathAccounts), server.listScheduledTransfers)
+	// freezes are for the bank to lift, so the status is changed by the admins
+	authRoutes.POST(fmt.Sprintf("%v/:id/status", pathAccounts), server.adminMiddleware(), server.changeAccountStatus)
+	authRoutes.POST(fmt.Sprintf("%v/:id/close", pathAccounts), server.closeAccount)
+	authRoutes.GET(fmt.Sprintf("%v/:id/limits", pathAccounts), server.getTransferAllowances)
+	authRoutes.GET(fmt.Sprintf("%v/:id/balance", pathAccounts), server.getBalance)
+	authRoutes.GET(fmt.Sprintf("%v/:id/balance-history", pathAccounts), server.getBalanceHistory)
+	authRoutes.GET(fmt.Sprintf("%v/:id/statement", pathAccounts), server.getStatement)
+
+	authRoutes.POST(fmt.Sprintf("%v", pathTransfer), server.transferBtwAccounts)
+	authRoutes.POST(fmt.Sprintf("%v/quote", pathTransfer), server.quoteTransfer)
+	authRoutes.GET(fmt.Sprintf("%v/:id", pathTransfers), server.getTransfer)
+	authRoutes.POST(fmt.Sprintf("%v/batch", pathTransfers), server.batchTransfer)
+	authRoutes.POST(fmt.Sprintf("%v/pain001", pathTransfers), server.importPaymentFile)
+	authRoutes.POST(fmt.Sprintf("%v/:id/reversal", pathTransfers), server.reverseTransfer)
 
-	router.POST(fmt.Sprintf("%v", pathTransfer), server.transferBtwAccounts)
+	authRoutes.POST(fmt.Sprintf("%v", pathHolds), server.authorizeHold)
+	authRoutes.GET(fmt.Sprintf("%v/:id", pathHolds), server.getHold)
+	authRoutes.POST(fmt.Sprintf("%v/:id/capture", pathHolds), server.captureHold)
+	authRoutes.POST(fmt.Sprintf("%v/:id/void", pathHolds), server.voidHold)
+
+	authRoutes.POST(fmt.Sprintf("%v", pathScheduled), server.createScheduledTransfer)
+	authRoutes.GET(fmt.Sprintf("%v/:id", pathScheduled), server.getScheduledTransfer)
+	authRoutes.PATCH(fmt.Sprintf("%v/:id", pathScheduled), server.updateScheduledTransfer)
+	authRoutes.DELETE(fmt.Sprintf("%v/:id", pathScheduled), server.cancelScheduledTransfer)
+	authRoutes.GET(fmt.Sprintf("%v/:id/runs", pathScheduled), server.listScheduledTransferRuns)
+
+	authRoutes.POST(fmt.Sprintf("%v/:id/block", pathSessions), server.blockSession)
+
+	authRoutes.POST(fmt.Sprintf("%v/quotes", pathFx), server.createRateQuote)
+
+	// Routes that also require the user to be an admin
+	adminRoutes := router.Group(pathAdmin).Use(authMiddleware(server.tokenMaker), server.adminMiddleware())
+
+	adminRoutes.GET("/reconciliation/latest", server.getLatestReconciliation)
+	adminRoutes.GET("/currencies", server.listCurrencies)
+	adminRoutes.POST("/currencies/:code/enable", server.enableCurrency)
+	adminRoutes.POST("/currencies/:code/disable", server.disableCurrency)
+	// metrics published with expvar, like the retries of the store transactions. They also show the
+	// command line and memory of the process, so they aren't public.
+	adminRoutes.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	server.router = router
 
-	return server
+	return server, nil
+}
+
+// newRateProvider loads the exchange rates from the file at path,
+// without a file there are no rates and only same currency transfers are possible
+func newRateProvider(path string) (fx.ExchangeRateProvider, error) {
+	if path == "" {
+		return fx.NewStaticProvider(nil)
+	}
+
+	return fx.NewFileProvider(path)
 }
 
 // Runs the HTTP server on a specific address
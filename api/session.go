@@ -0,0 +1,83 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+)
+
+var errSessionNotOwned = errors.New("session doesn't belong to the authenticated user")
+
+// sessionResponse is the session representation returned by the API,
+// it leaves out the refresh token
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Sessions) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		Email:     session.Email,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+type blockSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// blockSession revokes a session, so its refresh token can't be used to renew access tokens anymore
+func (s *Server) blockSession(ctx *gin.Context) {
+	var req blockSessionRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	sessionID, err := uuid.Parse(req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Email != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errSessionNotOwned))
+		return
+	}
+
+	session, err = s.store.BlockSession(ctx, sessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionResponse(session))
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/statement"
+)
+
+// statementRequest asks for the statement of the period [From, To), in RFC 3339
+type statementRequest struct {
+	From   time.Time `form:"from" binding:"required"`
+	To     time.Time `form:"to" binding:"required,gtfield=From"`
+	Format string    `form:"format" binding:"required,oneof=csv ofx camt053"`
+}
+
+// getStatement streams the statement of an account of the authenticated user: its opening balance,
+// the entries of the period and its closing balance
+func (s *Server) getStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req statementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	header := statement.Header{
+		Account:   account,
+		Currency:  s.currency(account.Currency),
+		From:      req.From,
+		To:        req.To,
+		CreatedAt: time.Now(),
+	}
+	w, err := statement.NewWriter(req.Format, ctx.Writer, header)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Type", statement.ContentType(req.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(req.Format, header)))
+	ctx.Status(http.StatusOK)
+
+	err = s.store.StatementTx(ctx, db.StatementTxParams{
+		AccountID: account.ID,
+		From:      req.From,
+		To:        req.To,
+	}, w)
+	if err != nil {
+		// once the statement started the status is sent, the client sees a truncated file
+		if ctx.Writer.Written() {
+			_ = ctx.Error(err)
+			return
+		}
+		ctx.Header("Content-Type", "")
+		ctx.Header("Content-Disposition", "")
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
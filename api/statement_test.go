@@ -0,0 +1,169 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatementAPI(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	account := mockAccount(user1)
+
+	from := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)
+	period := func(format string) url.Values {
+		return url.Values{
+			"from":   {from.Format(time.RFC3339)},
+			"to":     {to.Format(time.RFC3339)},
+			"format": {format},
+		}
+	}
+	entry := db.ListStatementEntriesRow{
+		ID:                    7,
+		Amount:                -250,
+		CreatedAt:             sql.NullTime{Time: from.Add(time.Hour), Valid: true},
+		TransferID:            sql.NullInt64{Int64: 3, Valid: true},
+		CounterpartyAccountID: 9,
+	}
+	writeStatement := func(_ interface{}, arg db.StatementTxParams, w db.StatementWriter) error {
+		require.Equal(t, account.ID, arg.AccountID)
+		require.True(t, from.Equal(arg.From))
+		require.True(t, to.Equal(arg.To))
+
+		require.NoError(t, w.Begin(1000, 750))
+		require.NoError(t, w.Entry(entry))
+		return w.End()
+	}
+
+	testCases := []struct {
+		name          string
+		user          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "CSV",
+			user:  user1,
+			query: period("csv"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(writeStatement)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"),
+					fmt.Sprintf("statement-%d-20230301-20230401.csv", account.ID))
+
+				rows, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, 4)
+				require.Equal(t, "10.00", rows[1][7])
+				require.Equal(t, "-2.50", rows[2][6])
+				require.Equal(t, "7.50", rows[3][7])
+			},
+		},
+		{
+			name:  "OFX",
+			user:  user1,
+			query: period("ofx"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(writeStatement)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "<TRNAMT>-2.50</TRNAMT>")
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			user:  user1,
+			query: period("pdf"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidPeriod",
+			user: user1,
+			query: url.Values{
+				"from":   {to.Format(time.RFC3339)},
+				"to":     {from.Format(time.RFC3339)},
+				"format": {"csv"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AccountNotOwned",
+			user:  user2,
+			query: period("csv"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			user:  user1,
+			query: period("camt053"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Content-Disposition"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
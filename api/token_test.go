@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/homocode/bank_demo/api/mock"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := mockUser(t)
+
+	testCases := []struct {
+		name          string
+		setupToken    func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := mockSession(refreshToken, payload)
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+			},
+		},
+		{
+			name: "AccessToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Email, token.PurposeAccess, time.Hour)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid-token", nil
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Sessions{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := mockSession(refreshToken, payload)
+				session.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := mockSession(refreshToken, payload)
+				session.RefreshToken = "another-token"
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordChangedAfterSession",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := mockSession(refreshToken, payload)
+				changedUser := user
+				changedUser.PasswordChangedAt = sql.NullTime{Time: session.CreatedAt.Add(time.Second), Valid: true}
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(changedUser, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return mockRefreshToken(t, tokenMaker, user.Email, time.Hour)
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Sessions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			refreshToken, payload := tc.setupToken(t, server.tokenMaker)
+			tc.buildStubs(store, refreshToken, payload)
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func mockRefreshToken(t *testing.T, tokenMaker token.Maker, email string, duration time.Duration) (string, *token.Payload) {
+	refreshToken, payload, err := tokenMaker.CreateToken(email, token.PurposeRefresh, duration)
+	require.NoError(t, err)
+	return refreshToken, payload
+}
+
+// mockSession builds the session persisted when the refresh token was issued
+func mockSession(refreshToken string, payload *token.Payload) db.Sessions {
+	return db.Sessions{
+		ID:           payload.ID,
+		Email:        payload.Email,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+		CreatedAt:    payload.IssuedAt,
+	}
+}
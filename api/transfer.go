@@ -0,0 +1,259 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+)
+
+var errTransferNotOwned = errors.New("transfer doesn't involve an account of the authenticated user")
+
+type getTransferRequest struct {
+	Id int64 `uri:"id" binding:"required,gt=0"`
+}
+
+// getTransfer returns a transfer to the owner of any of its accounts
+func (s *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := s.store.GetTransfer(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	fromAccount, err := s.store.GetAccount(ctx, transfer.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the ToAccount is needed to check the ownership when the user doesn't own the FromAccount,
+	// and for the currency of the to_amount of a cross-currency transfer
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	var toCurrency string
+	if fromAccount.Owner != authPayload.Email || transfer.ToAmount.Valid {
+		toAccount, err := s.store.GetAccount(ctx, transfer.ToAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if fromAccount.Owner != authPayload.Email && toAccount.Owner != authPayload.Email {
+			ctx.JSON(http.StatusForbidden, errorResponse(errTransferNotOwned))
+			return
+		}
+		toCurrency = toAccount.Currency
+	}
+
+	ctx.JSON(http.StatusOK, s.newTransferResponse(ctx, transfer, fromAccount.Currency, toCurrency))
+}
+
+// transferResponse adds to the transfer its amounts written for the locale of the request.
+// The amount is in the currency of the FromAccount, the to_amount in the one of the ToAccount.
+type transferResponse struct {
+	db.Transfers
+	AmountDisplay   string `json:"amount_display,omitempty"`
+	ToAmountDisplay string `json:"to_amount_display,omitempty"`
+}
+
+// newTransferResponse builds the response of a transfer, the amounts without currency aren't displayed
+func (s *Server) newTransferResponse(ctx *gin.Context, transfer db.Transfers, fromCurrency string, toCurrency string) transferResponse {
+	rsp := transferResponse{Transfers: transfer}
+	if fromCurrency != "" {
+		rsp.AmountDisplay = s.display(ctx, transfer.Amount, fromCurrency)
+	}
+	if transfer.ToAmount.Valid && toCurrency != "" {
+		rsp.ToAmountDisplay = s.display(ctx, transfer.ToAmount.Int64, toCurrency)
+	}
+	return rsp
+}
+
+// newAccountTransferResponse builds the response of a transfer listed by account. Only the amounts known
+// to be in the currency of the account are displayed, the other account isn't got for each transfer.
+func (s *Server) newAccountTransferResponse(ctx *gin.Context, transfer db.Transfers, account db.Accounts) transferResponse {
+	var fromCurrency, toCurrency string
+	if transfer.FromAccountID == account.ID {
+		fromCurrency = account.Currency
+	}
+	if transfer.ToAccountID == account.ID {
+		toCurrency = account.Currency
+		// in a transfer within a currency both accounts have it
+		if !transfer.ToAmount.Valid {
+			fromCurrency = account.Currency
+		}
+	}
+
+	return s.newTransferResponse(ctx, transfer, fromCurrency, toCurrency)
+}
+
+// ownsAnyAccount checks the authenticated user owns at least one of the accounts,
+// otherwise it writes the error response, 403 with errNotOwned, and returns false
+func (s *Server) ownsAnyAccount(ctx *gin.Context, errNotOwned error, accountIDs ...int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range accountIDs {
+		account, err := s.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+
+		if account.Owner == authPayload.Email {
+			return true
+		}
+	}
+
+	ctx.JSON(http.StatusForbidden, errorResponse(errNotOwned))
+	return false
+}
+
+// listTransfers lists the transfers from and to an account of the authenticated user, oldest first
+func (s *Server) listTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req historyRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	if req.PageId != 0 {
+		ctx.Header(deprecationHeader, "true")
+
+		arg := db.ListTransfersParams{
+			AccountID:   account.ID,
+			Outgoing:    req.outgoing(),
+			Incoming:    req.incoming(),
+			CreatedFrom: req.createdFrom(),
+			CreatedTo:   req.createdTo(),
+			MinAmount:   req.minAmount(),
+			MaxAmount:   req.maxAmount(),
+			Limit:       req.PageSize,
+			Offset:      req.offset(),
+		}
+
+		transfers, err := s.store.ListTransfers(ctx, arg)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, mapItems(transfers, func(transfer db.Transfers) transferResponse {
+			return s.newAccountTransferResponse(ctx, transfer, account)
+		}))
+		return
+	}
+
+	after, err := s.decodeCursor(ctx, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListTransfersAfterParams{
+		AccountID:      account.ID,
+		Outgoing:       req.outgoing(),
+		Incoming:       req.incoming(),
+		CreatedFrom:    req.createdFrom(),
+		CreatedTo:      req.createdTo(),
+		MinAmount:      req.minAmount(),
+		MaxAmount:      req.maxAmount(),
+		AfterCreatedAt: after.CreatedAt,
+		AfterID:        after.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	transfers, err := s.store.ListTransfersAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := newListResponse(s, ctx, transfers, req.PageSize, func(transfer db.Transfers) pageCursor {
+		return pageCursor{CreatedAt: transfer.CreatedAt.Time, ID: transfer.ID}
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapListResponse(rsp, func(transfer db.Transfers) transferResponse {
+		return s.newAccountTransferResponse(ctx, transfer, account)
+	}))
+}
+
+type reverseTransferRequest struct {
+	// Amount to refund, without it all that wasn't reversed yet is refunded
+	Amount int64 `json:"amount" binding:"omitempty,amount"`
+}
+
+// reverseTransfer refunds a transfer to its sender, with the part of its fee of the amount refunded.
+// Only the owner of the account that received the money can refund it.
+func (s *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, without it the whole transfer is reversed
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := s.store.GetTransfer(ctx, uri.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, valid := s.ownedAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return
+	}
+
+	result, err := s.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrTransferNotReversible) ||
+			errors.Is(err, db.ErrReversalExceedsAmount) || errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.newTransferTxResponse(ctx, result))
+}
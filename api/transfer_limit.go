@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTransferAllowances lists the transfer limits of an account of the authenticated user,
+// with how much was used in the current period and how much can still be transferred
+func (s *Server) getTransferAllowances(ctx *gin.Context) {
+	var req getAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.ownedAccount(ctx, req.Id)
+	if !valid {
+		return
+	}
+
+	allowances, err := s.store.TransferAllowances(ctx, account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, allowances)
+}
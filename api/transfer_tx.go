@@ -2,18 +2,27 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
+	"github.com/homocode/bank_demo/util"
 )
 
 type transferRequest struct {
-	FromAccountId int64  `json:"fromAccountId" binding:"required,min=1"`
-	ToAccountId   int64  `json:"toAccountId" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	FromAccountId int64 `json:"fromAccountId" binding:"required,min=1"`
+	ToAccountId   int64 `json:"toAccountId" binding:"required_without=ToAccountNumber,excluded_with=ToAccountNumber,omitempty,min=1"`
+	// ToAccountNumber identifies the ToAccount instead of ToAccountId, its check digits reject typos
+	ToAccountNumber string `json:"toAccountNumber" binding:"omitempty,account_number"`
+	Amount          int64  `json:"amount" binding:"required_without=AmountDecimal,excluded_with=AmountDecimal,omitempty,amount"`
+	// AmountDecimal is the amount as a decimal in the currency instead of minor units, "12.34" is 1234
+	AmountDecimal string `json:"amountDecimal"`
 	Currency      string `json:"currency" binding:"required,currency"`
+	// QuoteId is the rate quote to convert the amount with, when the accounts have different currencies
+	QuoteId int64 `json:"quoteId" binding:"omitempty,min=1"`
 }
 
 func (s *Server) transferBtwAccounts(ctx *gin.Context) {
@@ -24,11 +33,51 @@ func (s *Server) transferBtwAccounts(ctx *gin.Context) {
 		return
 	}
 
-	if !s.validAccount(ctx, req.FromAccountId, req.Currency) {
+	if err := s.parseAmount(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idem, idempotent, err := s.idempotencyParams(ctx, authPayload.Email, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountId, req.Currency, sender)
+	if !valid {
+		return
+	}
+
+	// only the owner of the account can move money out of it
+	if fromAccount.Owner != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	// early check to avoid opening a transaction that is going to fail,
+	// TransferTx checks it again with the account locked.
+	// It's skipped for idempotent requests, as the balance of a replay already reflects the transfer.
+	if !idempotent && fromAccount.Balance < req.Amount {
+		err := fmt.Errorf("%w: account %d has balance %d, transfer amount %d",
+			db.ErrInsufficientFunds, fromAccount.ID, fromAccount.Balance, req.Amount)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
 		return
 	}
 
-	if !s.validAccount(ctx, req.ToAccountId, req.Currency) {
+	// without a quote both accounts must have the transfer currency,
+	// with one the ToAccount must have the currency the quote converts to
+	toCurrency := req.Currency
+	if req.QuoteId != 0 {
+		quote, valid := s.validQuote(ctx, req.QuoteId, authPayload.Email, req.Currency)
+		if !valid {
+			return
+		}
+		toCurrency = quote.ToCurrency
+	}
+
+	if !s.validToAccount(ctx, &req, toCurrency) {
 		return
 	}
 
@@ -36,34 +85,266 @@ func (s *Server) transferBtwAccounts(ctx *gin.Context) {
 		FromAccountId: req.FromAccountId,
 		ToAccountId:   req.ToAccountId,
 		Amount:        req.Amount,
+		QuoteID:       req.QuoteId,
 	}
 
-	transfer, err := s.store.TransferTx(ctx, arg)
+	var transfer db.TransferTxResult
+	if idempotent {
+		var replayed bool
+		transfer, replayed, err = s.store.TransferTxIdempotent(ctx, idem, arg)
+		if replayed {
+			ctx.Header(idempotentReplayedHeader, "true")
+		}
+	} else {
+		transfer, err = s.store.TransferTx(ctx, arg)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.newTransferTxResponse(ctx, transfer))
+}
+
+// transferTxResponse is the db.TransferTxResult with the amounts written for the locale of the request.
+// It keeps the keys of db.TransferTxResult, the response of the transaction endpoints before it.
+type transferTxResponse struct {
+	Transfer    transferResponse
+	FromAccount accountResponse
+	ToAccount   accountResponse
+	FromEntry   entryResponse
+	ToEntry     entryResponse
+	Fee         int64
+	FeeDisplay  string
+	FeeEntry    entryResponse
+	// FeeRefund is set on reversals, in the currency of the ToAccount
+	FeeRefund        int64
+	FeeRefundDisplay string
+	FeeRefundEntry   entryResponse
+}
+
+func (s *Server) newTransferTxResponse(ctx *gin.Context, result db.TransferTxResult) transferTxResponse {
+	fromCurrency, toCurrency := result.FromAccount.Currency, result.ToAccount.Currency
+	return transferTxResponse{
+		Transfer:    s.newTransferResponse(ctx, result.Transfer, fromCurrency, toCurrency),
+		FromAccount: s.newAccountResponse(ctx, result.FromAccount),
+		ToAccount:   s.newAccountResponse(ctx, result.ToAccount),
+		FromEntry:   s.newEntryResponse(ctx, result.FromEntry, fromCurrency),
+		ToEntry:     s.newEntryResponse(ctx, result.ToEntry, toCurrency),
+		Fee:         result.Fee,
+		FeeDisplay:  s.display(ctx, result.Fee, fromCurrency),
+		FeeEntry:    s.newEntryResponse(ctx, result.FeeEntry, fromCurrency),
+
+		FeeRefund:        result.FeeRefund,
+		FeeRefundDisplay: s.display(ctx, result.FeeRefund, toCurrency),
+		FeeRefundEntry:   s.newEntryResponse(ctx, result.FeeRefundEntry, toCurrency),
+	}
+}
+
+// parseAmount sets the Amount of a transfer sent with AmountDecimal, in the exponent of its currency.
+// AmountDecimal is cleared, so the same transfer sent either way has the same idempotency fingerprint.
+func (s *Server) parseAmount(req *transferRequest) error {
+	if req.AmountDecimal == "" {
+		return nil
+	}
+
+	money, err := util.ParseMoney(req.AmountDecimal, s.currency(req.Currency))
+	if err != nil {
+		return err
+	}
+	if money.Amount <= 0 {
+		return fmt.Errorf("%w: amountDecimal must be greater than 0", util.ErrInvalidAmount)
+	}
+	if money.Amount > util.MaxAmount {
+		return fmt.Errorf("%w: amountDecimal is over %s", util.ErrAmountOverflow, util.NewMoney(util.MaxAmount, s.currency(req.Currency)))
+	}
+
+	req.Amount = money.Amount
+	req.AmountDecimal = ""
+	return nil
+}
+
+// transferErrorStatus is the status of the response to a transfer that failed with err,
+// the transfer can't be booked as requested or something went wrong
+func transferErrorStatus(err error) int {
+	if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrIdempotencyKeyReused) ||
+		errors.Is(err, db.ErrQuoteExpired) || errors.Is(err, db.ErrQuoteUsed) || errors.Is(err, db.ErrQuoteMismatch) ||
+		errors.Is(err, db.ErrAccountFrozen) || errors.Is(err, db.ErrAccountClosed) || errors.Is(err, db.ErrLimitExceeded) ||
+		errors.Is(err, util.ErrAmountOverflow) {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusInternalServerError
+}
+
+// transferQuoteResponse is what a transfer would take out of the FromAccount, in its currency
+type transferQuoteResponse struct {
+	Amount        int64  `json:"amount"`
+	Fee           int64  `json:"fee"`
+	Total         int64  `json:"total"`
+	Currency      string `json:"currency"`
+	AmountDisplay string `json:"amount_display"`
+	FeeDisplay    string `json:"fee_display"`
+	TotalDisplay  string `json:"total_display"`
+}
+
+// quoteTransfer validates a transfer like transferBtwAccounts, without booking it,
+// and answers with the fee it would be charged
+func (s *Server) quoteTransfer(ctx *gin.Context) {
+	var req transferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := s.parseAmount(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountId, req.Currency, sender)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Email {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	toCurrency := req.Currency
+	if req.QuoteId != 0 {
+		quote, valid := s.validQuote(ctx, req.QuoteId, authPayload.Email, req.Currency)
+		if !valid {
+			return
+		}
+		toCurrency = quote.ToCurrency
+	}
+
+	if !s.validToAccount(ctx, &req, toCurrency) {
+		return
+	}
+
+	fee, err := s.store.TransferFee(ctx, fromAccount, req.Amount)
+	if err != nil {
+		ctx.JSON(transferErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, transfer)
+	total, err := util.AddAmounts(req.Amount, fee)
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferQuoteResponse{
+		Amount:        req.Amount,
+		Fee:           fee,
+		Total:         total,
+		Currency:      fromAccount.Currency,
+		AmountDisplay: s.display(ctx, req.Amount, fromAccount.Currency),
+		FeeDisplay:    s.display(ctx, fee, fromAccount.Currency),
+		TotalDisplay:  s.display(ctx, total, fromAccount.Currency),
+	})
 }
 
-func (s *Server) validAccount(ctx *gin.Context, accountId int64, currency string) bool {
+// accountRole is the side an account takes in a movement of money, frozen accounts can only receive
+type accountRole int
+
+const (
+	sender accountRole = iota
+	recipient
+)
+
+// validAccount gets the account and checks it has the currency and its status allows it to take
+// the role in the movement, otherwise it writes the error response and returns false
+func (s *Server) validAccount(ctx *gin.Context, accountId int64, currency string, role accountRole) (db.Accounts, bool) {
 	account, err := s.store.GetAccount(ctx, accountId)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
+			return account, false
 		}
 
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	if status, err := checkAccount(account, currency, role); err != nil {
+		ctx.JSON(status, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// validToAccount checks the ToAccount of the transfer like validAccount, getting it by number when
+// the transfer has one. Then it sets ToAccountId to the id of the account.
+func (s *Server) validToAccount(ctx *gin.Context, req *transferRequest, currency string) bool {
+	if req.ToAccountNumber == "" {
+		_, valid := s.validAccount(ctx, req.ToAccountId, currency, recipient)
+		return valid
+	}
+
+	account, err := s.store.GetAccountByNumber(ctx, util.NormalizeAccountNumber(req.ToAccountNumber))
+	if err != nil {
+		ctx.JSON(accountErrorStatus(err), errorResponse(err))
+		return false
+	}
+
+	if status, err := checkAccount(account, currency, recipient); err != nil {
+		ctx.JSON(status, errorResponse(err))
 		return false
 	}
 
+	req.ToAccountId = account.ID
+	return true
+}
+
+// checkAccount checks the account has the currency and its status allows it to take the role,
+// otherwise it returns the error with the status of the response
+func checkAccount(account db.Accounts, currency string, role accountRole) (int, error) {
 	if account.Currency != currency {
 		err := fmt.Errorf("account with id %d, currency mismatch, want: %s got: %s", account.ID, currency, account.Currency)
+		return http.StatusBadRequest, err
+	}
+
+	switch {
+	case account.Status == db.AccountClosed:
+		return http.StatusUnprocessableEntity, fmt.Errorf("%w: account %d", db.ErrAccountClosed, account.ID)
+	case account.Status == db.AccountFrozen && role == sender:
+		return http.StatusUnprocessableEntity, fmt.Errorf("%w: account %d can't send money", db.ErrAccountFrozen, account.ID)
+	}
+
+	return http.StatusOK, nil
+}
+
+// validQuote checks the quote belongs to the user and converts from the transfer currency.
+// Expiration and reuse are checked by TransferTx, with the quote locked.
+func (s *Server) validQuote(ctx *gin.Context, quoteId int64, owner string, currency string) (db.RateQuotes, bool) {
+	quote, err := s.store.GetRateQuote(ctx, quoteId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return quote, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return quote, false
+	}
+
+	if quote.Owner != owner {
+		ctx.JSON(http.StatusForbidden, errorResponse(errQuoteNotOwned))
+		return quote, false
+	}
+
+	if quote.FromCurrency != currency {
+		err := fmt.Errorf("quote with id %d, currency mismatch, want: %s got: %s", quote.ID, currency, quote.FromCurrency)
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		return quote, false
 	}
 
-	return true
+	return quote, true
 }
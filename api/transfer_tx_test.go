@@ -2,16 +2,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/homocode/bank_demo/api/mock"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/token"
 	"github.com/homocode/bank_demo/util"
 	"github.com/stretchr/testify/require"
 )
@@ -20,18 +23,28 @@ func TestTransferAPI(t *testing.T) {
 	amount := int64(10)
 
 	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+	user3 := util.RandomOwner()
 
 	account1 := mockAccount(user1)
-	account2 := mockAccount(user1)
-	account3 := mockAccount(user1)
+	account2 := mockAccount(user2)
+	account3 := mockAccount(user3)
 
 	account1.Currency = util.USD
+	// make sure the account can afford the transfer
+	account1.Balance += amount
 	account2.Currency = util.USD
 	account3.Currency = util.EUR
 
+	frozenAccount1 := account1
+	frozenAccount1.Status = db.AccountFrozen
+	frozenAccount2 := account2
+	frozenAccount2.Status = db.AccountFrozen
+
 	testCases := []struct {
 		name          string
 		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
@@ -43,6 +56,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
@@ -56,6 +72,91 @@ func TestTransferAPI(t *testing.T) {
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// the keys of db.TransferTxResult, the response before the display fields
+				var got map[string]json.RawMessage
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				for _, key := range []string{"Transfer", "FromAccount", "ToAccount", "FromEntry", "ToEntry", "Fee", "FeeDisplay", "FeeEntry"} {
+					require.Contains(t, got, key)
+				}
+			},
+		},
+		{
+			name: "FrozenSender",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(frozenAccount1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "FrozenRecipient",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(frozenAccount2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
@@ -66,6 +167,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
@@ -83,6 +187,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
@@ -92,6 +199,110 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name: "ToAccountNumber",
+			body: gin.H{
+				"fromAccountId":   account1.ID,
+				"toAccountNumber": util.FormatAccountNumber(account2.AccountNumber),
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountNumberTypo",
+			body: gin.H{
+				"fromAccountId":   account1.ID,
+				"toAccountNumber": accountNumberTypo(account2.AccountNumber),
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountNumberNotFound",
+			body: gin.H{
+				"fromAccountId":   account1.ID,
+				"toAccountNumber": account2.AccountNumber,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountIdAndNumber",
+			body: gin.H{
+				"fromAccountId":   account1.ID,
+				"toAccountId":     account2.ID,
+				"toAccountNumber": account2.AccountNumber,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoToAccount",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "FromAccountCurrencyMismatch",
 			body: gin.H{
@@ -100,6 +311,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
@@ -117,6 +331,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
@@ -134,6 +351,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      "XYZ",
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
@@ -150,6 +370,28 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        -amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AmountOverMax",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        util.MaxAmount + 1,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
@@ -166,6 +408,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
@@ -174,6 +419,76 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        account1.Balance + 1,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "LimitExceeded",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.LimitExceededError{
+						Allowance: db.TransferAllowance{
+							Limit:     db.TransferLimits{ID: 1, Period: db.LimitDaily, Amount: amount},
+							Used:      amount,
+							Remaining: 0,
+						},
+						Amount: amount,
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TransferTxInsufficientFunds",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
 		{
 			name: "TransferTxError",
 			body: gin.H{
@@ -182,6 +497,9 @@ func TestTransferAPI(t *testing.T) {
 				"amount":        amount,
 				"currency":      util.USD,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
@@ -203,7 +521,7 @@ func TestTransferAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			// Marshal body data to JSON
@@ -214,6 +532,374 @@ func TestTransferAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// accountNumberTypo changes the last digit of the account number
+func accountNumberTypo(number string) string {
+	last := number[len(number)-1]
+	return number[:len(number)-1] + string('0'+(last-'0'+1)%10)
+}
+
+func TestTransferIdempotencyAPI(t *testing.T) {
+	amount := int64(10)
+	idempotencyKey := util.RandomString(16)
+
+	user1 := util.RandomOwner()
+	account1 := mockAccount(user1)
+	account2 := mockAccount(util.RandomOwner())
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	body := gin.H{
+		"fromAccountId": account1.ID,
+		"toAccountId":   account2.ID,
+		"amount":        amount,
+		"currency":      util.USD,
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+	}
+
+	// the fingerprint itself is covered by TestIdempotencyParamsFingerprint
+	matchIdem := gomock.AssignableToTypeOf(db.IdempotencyParams{})
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstRequest",
+			key:  idempotencyKey,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					TransferTxIdempotent(gomock.Any(), matchIdem, gomock.Eq(arg)).
+					Times(1).
+					DoAndReturn(func(_ context.Context, idem db.IdempotencyParams, _ db.TransferTxParams) (db.TransferTxResult, bool, error) {
+						require.Equal(t, user1, idem.Owner)
+						require.Equal(t, idempotencyKey, idem.Key)
+						require.Equal(t, "/transfer", idem.RequestPath)
+						require.NotEmpty(t, idem.RequestHash)
+						return db.TransferTxResult{}, false, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "Replay",
+			key:  idempotencyKey,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTxIdempotent(gomock.Any(), matchIdem, gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, true, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "KeyReused",
+			key:  idempotencyKey,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTxIdempotent(gomock.Any(), matchIdem, gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, false, db.ErrIdempotencyKeyReused)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "KeyTooLong",
+			key:  util.RandomString(maxIdempotencyKeyLength + 1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTxIdempotent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			url := "/transfer"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set(idempotencyKeyHeader, tc.key)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestIdempotencyParamsFingerprint(t *testing.T) {
+	server := newTestServer(t, nil)
+	owner := util.RandomOwner()
+
+	fingerprint := func(req transferRequest) string {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/transfer", nil)
+		ctx.Request.Header.Set(idempotencyKeyHeader, "key")
+
+		idem, ok, err := server.idempotencyParams(ctx, owner, req)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return idem.RequestHash
+	}
+
+	req := transferRequest{FromAccountId: 1, ToAccountId: 2, Amount: 10, Currency: util.USD}
+	sameReq := req
+	otherReq := req
+	otherReq.Amount = 11
+
+	require.Equal(t, fingerprint(req), fingerprint(sameReq))
+	require.NotEqual(t, fingerprint(req), fingerprint(otherReq))
+}
+
+func TestQuoteTransferAPI(t *testing.T) {
+	amount := int64(1000)
+
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := mockAccount(user1)
+	account1.ID = 1
+	account1.Currency = util.USD
+	account2 := mockAccount(user2)
+	account2.ID = 2
+	account2.Currency = util.USD
+
+	okStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+		store.EXPECT().TransferFee(gomock.Any(), gomock.Eq(account1), gomock.Eq(amount)).Times(1).Return(int64(15), nil)
+	}
+
+	testCases := []struct {
+		name           string
+		body           gin.H
+		user           string
+		acceptLanguage string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferFee(gomock.Any(), gomock.Eq(account1), gomock.Eq(amount)).Times(1).Return(int64(15), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var quote transferQuoteResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &quote)
+				require.NoError(t, err)
+				require.Equal(t, transferQuoteResponse{
+					Amount:        amount,
+					Fee:           15,
+					Total:         amount + 15,
+					Currency:      util.USD,
+					AmountDisplay: "USD 10.00",
+					FeeDisplay:    "USD 0.15",
+					TotalDisplay:  "USD 10.15",
+				}, quote)
+			},
+		},
+		{
+			name: "AmountDecimal",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amountDecimal": "10.0",
+				"currency":      util.USD,
+			},
+			user:           user1,
+			acceptLanguage: "es-AR,es;q=0.9,en;q=0.8",
+			buildStubs:     okStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var quote transferQuoteResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &quote)
+				require.NoError(t, err)
+				require.Equal(t, amount, quote.Amount)
+				require.Equal(t, "10,15 USD", quote.TotalDisplay)
+			},
+		},
+		{
+			name: "AmountDecimalTooManyDecimals",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amountDecimal": "10.001",
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AmountDecimalNotPositive",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amountDecimal": "-10",
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AmountDecimalOverMax",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amountDecimal": "92233720368547758.07",
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AmountAndAmountDecimal",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"amountDecimal": "10.00",
+				"currency":      util.USD,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotOwned",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.USD,
+			},
+			user: user2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferFee(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"fromAccountId": account1.ID,
+				"toAccountId":   account2.ID,
+				"amount":        amount,
+				"currency":      util.EUR,
+			},
+			user: user1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferFee(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer/quote", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set(acceptLanguageHeader, tc.acceptLanguage)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user, time.Minute)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
@@ -1,13 +1,45 @@
 package api
 
 import (
+	"sync/atomic"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/homocode/bank_demo/util"
 )
 
+// currencyRegistry is the registry of the last server created. The validator engine of gin is global and
+// caches the validations of each struct, so validCurrency can't be bound to the registry of a server.
+// It's nil until NewServer sets it.
+var currencyRegistry atomic.Pointer[util.CurrencyRegistry]
+
+// validCurrency accepts the currencies enabled in the registry, none before a server is created
 var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
+	registry := currencyRegistry.Load()
+	if registry == nil {
+		return false
+	}
+
 	if currency, ok := fl.Field().Interface().(string); ok {
-		return util.IsCurrencySuported(currency)
+		return registry.IsEnabled(currency)
+	}
+
+	return false
+}
+
+// validAmount accepts the amounts in minor units from 1 to util.MaxAmount, so the fees and sums of
+// the amounts can't overflow
+var validAmount validator.Func = func(fl validator.FieldLevel) bool {
+	if amount, ok := fl.Field().Interface().(int64); ok {
+		return amount > 0 && amount <= util.MaxAmount
+	}
+
+	return false
+}
+
+// validAccountNumber accepts the account numbers with the right check digits, written with spaces or not
+var validAccountNumber validator.Func = func(fl validator.FieldLevel) bool {
+	if number, ok := fl.Field().Interface().(string); ok {
+		return util.IsValidAccountNumber(util.NormalizeAccountNumber(number))
 	}
 
 	return false
@@ -0,0 +1,17 @@
+CREATE TABLE "idempotency_keys" (
+  "owner" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_path" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_body" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("owner", "key")
+);
+
+CREATE INDEX ON "idempotency_keys" ("created_at");
+
+COMMENT ON COLUMN "idempotency_keys"."request_hash" IS 'fingerprint of the request payload';
+
+COMMENT ON COLUMN "idempotency_keys"."response_body" IS 'result returned to the first request, replayed to the retries';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("owner") REFERENCES "users" ("email");
\ No newline at end of file
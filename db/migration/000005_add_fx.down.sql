@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "rate_quotes";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fx_residue";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
\ No newline at end of file
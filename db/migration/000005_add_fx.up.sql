@@ -0,0 +1,32 @@
+CREATE TABLE "rate_quotes" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" bigint NOT NULL,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "fx_residue" bigint;
+
+CREATE INDEX ON "rate_quotes" ("owner");
+
+COMMENT ON COLUMN "rate_quotes"."rate" IS 'minor units of to_currency per minor unit of from_currency, scaled by 10^6';
+
+COMMENT ON COLUMN "rate_quotes"."transfer_id" IS 'transfer that used the quote, a quote can be used only once';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of the to account, set on cross-currency transfers';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'applied rate scaled by 10^6, set on cross-currency transfers';
+
+COMMENT ON COLUMN "transfers"."fx_residue" IS 'amount rounded off the conversion scaled by 10^6, set on cross-currency transfers';
+
+ALTER TABLE "rate_quotes" ADD FOREIGN KEY ("owner") REFERENCES "users" ("email");
+
+ALTER TABLE "rate_quotes" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
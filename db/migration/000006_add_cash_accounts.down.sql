@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "cash_accounts";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'cash@system.internal');
+
+DELETE FROM "accounts" WHERE "owner" = 'cash@system.internal';
+
+DELETE FROM "users" WHERE "email" = 'cash@system.internal';
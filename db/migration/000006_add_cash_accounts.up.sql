@@ -0,0 +1,19 @@
+CREATE TABLE "cash_accounts" (
+  "currency" varchar PRIMARY KEY,
+  "account_id" bigint UNIQUE NOT NULL
+);
+
+COMMENT ON TABLE "cash_accounts" IS 'internal account of each currency, the counterpart of deposits, withdrawals and conversions';
+
+ALTER TABLE "cash_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- the cash accounts belong to a system user, its empty password hash never matches so it can't log in
+INSERT INTO "users" ("email", "hashed_password", "full_name") VALUES ('cash@system.internal', '', 'System cash');
+
+INSERT INTO "accounts" ("owner", "balance", "currency") VALUES
+  ('cash@system.internal', 0, 'ARS'),
+  ('cash@system.internal', 0, 'USD'),
+  ('cash@system.internal', 0, 'EUR');
+
+INSERT INTO "cash_accounts" ("currency", "account_id")
+SELECT "currency", "id" FROM "accounts" WHERE "owner" = 'cash@system.internal';
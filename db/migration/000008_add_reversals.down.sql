@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "reversed_entry_id";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "transfer_id";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reversed_transfer_id";
@@ -0,0 +1,21 @@
+ALTER TABLE "transfers" ADD COLUMN "reversed_transfer_id" bigint;
+
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD COLUMN "reversed_entry_id" bigint;
+
+CREATE INDEX ON "transfers" ("reversed_transfer_id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "transfers"."reversed_transfer_id" IS 'transfer refunded by this one, set on reversals';
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that booked the entry, null for deposits and withdrawals';
+
+COMMENT ON COLUMN "entries"."reversed_entry_id" IS 'entry of the original transfer compensated by this one, set on reversals';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversed_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("reversed_entry_id") REFERENCES "entries" ("id");
@@ -0,0 +1,26 @@
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'authorized',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "holds" ("from_account_id", "status");
+
+COMMENT ON TABLE "holds" IS 'funds of from_account reserved for a later transfer to to_account';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive, the most that can be captured';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured or voided. An authorized hold stops reserving funds at expires_at';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer that settled the hold, set when captured';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "scheduled_transfer_runs";
+
+DROP TABLE IF EXISTS "scheduled_transfers";
\ No newline at end of file
@@ -0,0 +1,55 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "interval_unit" varchar,
+  "interval_count" int NOT NULL DEFAULT 1,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "starts_at" timestamptz NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "next_attempt_at" timestamptz NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "attempt" int NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "scheduled_transfers" ("from_account_id");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "next_attempt_at");
+
+CREATE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id");
+
+COMMENT ON COLUMN "scheduled_transfers"."interval_unit" IS 'day, week or month, null for transfers that run once';
+
+COMMENT ON COLUMN "scheduled_transfers"."interval_count" IS 'units between runs, 2 with month runs every other month';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active, completed, failed or cancelled. Only active transfers run';
+
+COMMENT ON COLUMN "scheduled_transfers"."starts_at" IS 'first occurrence, the following ones are counted from it';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'when the next occurrence is due';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_attempt_at" IS 'when the worker picks it up, later than next_run_at while retrying a failed run';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts of the next occurrence';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'transfer booked by the run, null when it failed';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'why the run failed, null when it succeeded';
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
\ No newline at end of file
@@ -0,0 +1,21 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" varchar NOT NULL,
+  "to_status" varchar NOT NULL,
+  "reason" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "account_status_changes" ("account_id");
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed. Frozen accounts can receive but not send money, closed ones neither';
+
+COMMENT ON COLUMN "account_status_changes"."changed_by" IS 'email of the user that changed the status';
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("email");
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint,
+  "owner" varchar,
+  "currency" varchar NOT NULL,
+  "period" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("account_id" IS NULL OR "owner" IS NULL)
+);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ((COALESCE("account_id", 0)), (COALESCE("owner", '')), "currency", "period");
+
+COMMENT ON TABLE "transfer_limits" IS 'most that can be transferred out of accounts in a currency, every limit that matches an account applies';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'account limited, null for limits of an owner or defaults';
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'user whose accounts in the currency are limited together, null for limits of an account or defaults';
+
+COMMENT ON COLUMN "transfer_limits"."period" IS 'transaction, day or month. Days and months start at midnight UTC';
+
+COMMENT ON COLUMN "transfer_limits"."amount" IS 'most that can be transferred in the period';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("email");
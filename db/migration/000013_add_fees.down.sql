@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "revenue_accounts";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'revenue@system.internal');
+
+DELETE FROM "accounts" WHERE "owner" = 'revenue@system.internal';
+
+DELETE FROM "users" WHERE "email" = 'revenue@system.internal';
+
+DROP TABLE IF EXISTS "fee_schedules";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "tier";
@@ -0,0 +1,44 @@
+ALTER TABLE "accounts" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "tier" varchar,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percentage_bps" bigint NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "revenue_accounts" (
+  "currency" varchar PRIMARY KEY,
+  "account_id" bigint UNIQUE NOT NULL
+);
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", (COALESCE("tier", '')));
+
+COMMENT ON COLUMN "accounts"."tier" IS 'pricing tier, picks the fee schedule of the account';
+
+COMMENT ON TABLE "fee_schedules" IS 'fee charged to the sender of a transfer, flat_fee plus percentage_bps of the amount bounded by min_fee and max_fee';
+
+COMMENT ON COLUMN "fee_schedules"."tier" IS 'tier of the accounts charged, null applies to the tiers without a schedule of their own';
+
+COMMENT ON COLUMN "fee_schedules"."percentage_bps" IS 'basis points of the amount, 100 is 1%';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'null for no maximum';
+
+COMMENT ON TABLE "revenue_accounts" IS 'internal account of each currency that collects the fees';
+
+ALTER TABLE "revenue_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- the revenue accounts belong to a system user that can't log in, like the cash accounts
+INSERT INTO "users" ("email", "hashed_password", "full_name") VALUES ('revenue@system.internal', '', 'System revenue');
+
+INSERT INTO "accounts" ("owner", "balance", "currency") VALUES
+  ('revenue@system.internal', 0, 'ARS'),
+  ('revenue@system.internal', 0, 'USD'),
+  ('revenue@system.internal', 0, 'EUR');
+
+INSERT INTO "revenue_accounts" ("currency", "account_id")
+SELECT "currency", "id" FROM "accounts" WHERE "owner" = 'revenue@system.internal';
@@ -0,0 +1,38 @@
+CREATE TABLE "reconciliation_reports" (
+  "id" bigserial PRIMARY KEY,
+  "accounts_checked" bigint NOT NULL,
+  "transfers_checked" bigint NOT NULL,
+  "discrepancies" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "report_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "currency" varchar,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL
+);
+
+CREATE INDEX ON "reconciliation_discrepancies" ("report_id");
+
+COMMENT ON TABLE "reconciliation_reports" IS 'result of a run of the reconciliation of the ledger';
+
+COMMENT ON COLUMN "reconciliation_reports"."discrepancies" IS 'discrepancies found, zero when the ledger reconciles';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."kind" IS 'account_balance, currency_balance, transfer_entries or transfer_balance';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."account_id" IS 'account that doesn''t reconcile, set on account_balance';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."transfer_id" IS 'transfer that doesn''t reconcile, set on transfer_entries and transfer_balance';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."currency" IS 'null on transfer_entries';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."expected" IS 'value the invariant requires';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."actual" IS 'value found in the ledger';
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("report_id") REFERENCES "reconciliation_reports" ("id");
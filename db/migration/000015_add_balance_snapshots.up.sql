@@ -0,0 +1,13 @@
+CREATE TABLE "account_balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "day" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "day")
+);
+
+COMMENT ON TABLE "account_balance_snapshots" IS 'balance of each account at the end of a day, in UTC, so balances in the past don''t add up all the entries';
+
+COMMENT ON COLUMN "account_balance_snapshots"."balance" IS 'sum of the entries of the account created before the day ended';
+
+ALTER TABLE "account_balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,28 @@
+-- new_account_number makes an IBAN-like number: the user-assigned country code XB, so it can't be taken
+-- for a real IBAN, 2 check digits and 12 random digits. Moving the first 4 characters to the end and
+-- the letters to numbers (X is 33, B is 11) the check digits make the number 1 modulo 97.
+CREATE FUNCTION new_account_number() RETURNS varchar AS $$
+DECLARE
+  bban varchar;
+  candidate varchar;
+BEGIN
+  LOOP
+    bban := lpad(floor(random() * 1e12)::bigint::text, 12, '0');
+    candidate := 'XB' || lpad((98 - (bban || '331100')::numeric % 97)::text, 2, '0') || bban;
+    EXIT WHEN NOT EXISTS (SELECT 1 FROM "accounts" WHERE "account_number" = candidate);
+  END LOOP;
+  RETURN candidate;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar;
+
+UPDATE "accounts" SET "account_number" = new_account_number();
+
+ALTER TABLE "accounts"
+  ALTER COLUMN "account_number" SET NOT NULL,
+  ALTER COLUMN "account_number" SET DEFAULT new_account_number();
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number");
+
+COMMENT ON COLUMN "accounts"."account_number" IS 'number people use to identify the account, XB, 2 mod-97 check digits and 12 random digits';
@@ -0,0 +1,18 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "numeric_code" varchar UNIQUE NOT NULL,
+  "exponent" int NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "currencies" IS 'ISO 4217 currencies, accounts and transfers can only be created in the enabled ones';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'decimals of the minor unit the amounts are stored in, 2 for cents';
+
+INSERT INTO "currencies" ("code", "numeric_code", "exponent") VALUES
+  ('ARS', '032', 2),
+  ('EUR', '978', 2),
+  ('USD', '840', 2);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
@@ -0,0 +1,3 @@
+-- The backfilled transfer_id can't be told apart from the ones set when the entries were booked,
+-- they are dropped with the column by 000008 down.
+SELECT 1;
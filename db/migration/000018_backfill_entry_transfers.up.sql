@@ -0,0 +1,26 @@
+-- The entries booked before 000008 have no transfer_id, so the reconciliation would flag their transfers
+-- forever. A transfer and its entries were inserted in the same transaction and now() is the start of it,
+-- so they share created_at. Transfers and entries that tie on account, amount and created_at are paired
+-- in the order they were inserted.
+WITH unlinked_transfers AS (
+  SELECT * FROM "transfers"
+  WHERE NOT EXISTS (SELECT 1 FROM "entries" WHERE "entries"."transfer_id" = "transfers"."id")
+), sides AS (
+  SELECT "id" AS "transfer_id", "from_account_id" AS "account_id", -"amount" AS "amount", "created_at"
+  FROM unlinked_transfers
+  UNION ALL
+  SELECT "id", "to_account_id", COALESCE("to_amount", "amount"), "created_at"
+  FROM unlinked_transfers
+), numbered_sides AS (
+  SELECT *, row_number() OVER (PARTITION BY "account_id", "amount", "created_at" ORDER BY "transfer_id") AS "position"
+  FROM sides
+), numbered_entries AS (
+  SELECT "id", "account_id", "amount", "created_at",
+    row_number() OVER (PARTITION BY "account_id", "amount", "created_at" ORDER BY "id") AS "position"
+  FROM "entries"
+  WHERE "transfer_id" IS NULL
+)
+UPDATE "entries" SET "transfer_id" = numbered_sides."transfer_id"
+FROM numbered_sides
+JOIN numbered_entries USING ("account_id", "amount", "created_at", "position")
+WHERE "entries"."id" = numbered_entries."id";
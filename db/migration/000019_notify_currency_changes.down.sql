@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "currencies_notify_changes" ON "currencies";
+
+DROP FUNCTION IF EXISTS notify_currency_changes();
@@ -0,0 +1,12 @@
+-- every change of the currencies is notified on the currencies channel, the servers listen to it
+-- to reload their registry
+CREATE FUNCTION notify_currency_changes() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('currencies', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "currencies_notify_changes"
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "currencies"
+FOR EACH STATEMENT EXECUTE FUNCTION notify_currency_changes();
@@ -0,0 +1,16 @@
+ALTER TABLE "entries" ADD COLUMN "fee" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "entries"."fee" IS 'charges or collects the fee of its transfer, the other entries of the transfer move its amount';
+
+-- The fee was charged after the entries of the amount, so the fee entries are the ones of the revenue
+-- accounts and the entries of the sender that follow its first one in the transfer
+UPDATE "entries" SET "fee" = true
+FROM "transfers"
+WHERE "transfers"."id" = "entries"."transfer_id"
+  AND (
+    "entries"."account_id" IN (SELECT "account_id" FROM "revenue_accounts")
+    OR ("entries"."account_id" = "transfers"."from_account_id" AND "entries"."id" > (
+      SELECT min("id") FROM "entries" AS "first_entries"
+      WHERE "first_entries"."transfer_id" = "transfers"."id" AND "first_entries"."account_id" = "transfers"."from_account_id"
+    ))
+  );
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "transfer_limits_account_id_currency_period_idx";
+
+ALTER TABLE "transfer_limits" ADD COLUMN "owner" varchar;
+
+ALTER TABLE "transfer_limits" ADD CHECK ("account_id" IS NULL OR "owner" IS NULL);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ((COALESCE("account_id", 0)), (COALESCE("owner", '')), "currency", "period");
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'account limited, null for limits of an owner or defaults';
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'user whose accounts in the currency are limited together, null for limits of an account or defaults';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("email");
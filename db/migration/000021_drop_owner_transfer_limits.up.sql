@@ -0,0 +1,21 @@
+-- An owner has a single account in each currency, so a limit of the owner in a currency only ever
+-- limited that account. They become limits of the account, unless the account already has its own.
+INSERT INTO "transfer_limits" ("account_id", "currency", "period", "amount", "created_at")
+SELECT "accounts"."id", "transfer_limits"."currency", "transfer_limits"."period", "transfer_limits"."amount", "transfer_limits"."created_at"
+FROM "transfer_limits"
+JOIN "accounts" ON "accounts"."owner" = "transfer_limits"."owner" AND "accounts"."currency" = "transfer_limits"."currency"
+WHERE NOT EXISTS (
+  SELECT 1 FROM "transfer_limits" AS "account_limits"
+  WHERE "account_limits"."account_id" = "accounts"."id"
+    AND "account_limits"."currency" = "transfer_limits"."currency"
+    AND "account_limits"."period" = "transfer_limits"."period"
+);
+
+DELETE FROM "transfer_limits" WHERE "owner" IS NOT NULL;
+
+-- drops the check and the unique index on the owner with it
+ALTER TABLE "transfer_limits" DROP COLUMN "owner";
+
+CREATE UNIQUE INDEX "transfer_limits_account_id_currency_period_idx" ON "transfer_limits" ((COALESCE("account_id", 0)), "currency", "period");
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'account limited, null for defaults';
@@ -0,0 +1,3 @@
+ALTER TABLE "holds" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "holds"."fee" IS 'fee of a transfer of the amount when authorized, reserved with the amount. A capture is charged up to it';
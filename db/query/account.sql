@@ -12,6 +12,15 @@ RETURNING *;
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 LIMIT 1;
+
+-- name: GetAccountForUpdate :one
+SELECT * FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE owner = $1
@@ -24,3 +33,35 @@ UPDATE accounts
 SET balance = balance + sqlc.arg(amount) -- equal to: balance + $1
 WHERE id = sqlc.arg(id) -- equal to: $2
 RETURNING *;
+
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateAccountStatusChange :one
+INSERT INTO account_status_changes (
+    account_id,
+    from_status,
+    to_status,
+    reason,
+    changed_by
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: UpdateAccountTier :one
+UPDATE accounts
+SET tier = $2
+WHERE id = $1
+RETURNING *;
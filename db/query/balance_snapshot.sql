@@ -0,0 +1,53 @@
+-- name: CreateBalanceSnapshots :execrows
+INSERT INTO account_balance_snapshots (
+    account_id,
+    day,
+    balance
+)
+SELECT accounts.id, sqlc.arg(day)::date,
+    COALESCE(previous.balance, 0) + COALESCE((
+        SELECT sum(entries.amount) FROM entries
+        WHERE entries.account_id = accounts.id
+          AND entries.created_at >= COALESCE((previous.day + 1)::timestamp AT TIME ZONE 'UTC', '-infinity')
+          AND entries.created_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC'
+    ), 0)
+FROM accounts
+LEFT JOIN LATERAL (
+    SELECT day, balance FROM account_balance_snapshots
+    WHERE account_balance_snapshots.account_id = accounts.id
+      AND account_balance_snapshots.day < sqlc.arg(day)::date
+    ORDER BY account_balance_snapshots.day DESC
+    LIMIT 1
+) previous ON true
+WHERE accounts.created_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC'
+ON CONFLICT (account_id, day) DO NOTHING;
+
+-- name: GetBalanceAt :one
+WITH snapshot AS (
+    SELECT day, balance FROM account_balance_snapshots
+    WHERE account_balance_snapshots.account_id = sqlc.arg(account_id)
+      AND (day + 1)::timestamp AT TIME ZONE 'UTC' <= sqlc.arg(at)::timestamptz
+    ORDER BY day DESC
+    LIMIT 1
+)
+SELECT (COALESCE((SELECT balance FROM snapshot), 0) + COALESCE(sum(entries.amount), 0))::bigint AS balance
+FROM entries
+WHERE entries.account_id = sqlc.arg(account_id)
+  AND entries.created_at >= COALESCE((SELECT (day + 1)::timestamp AT TIME ZONE 'UTC' FROM snapshot), '-infinity')
+  AND entries.created_at < sqlc.arg(at)::timestamptz;
+
+-- name: ListBalanceSnapshots :many
+SELECT * FROM account_balance_snapshots
+WHERE account_id = sqlc.arg(account_id)
+  AND day >= sqlc.arg(from_day)::date
+  AND day <= sqlc.arg(to_day)::date
+ORDER BY day;
+
+-- name: ListDailyEntryTotals :many
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day, sum(amount)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(created_from)::timestamptz
+  AND created_at < sqlc.arg(created_to)::timestamptz
+GROUP BY day
+ORDER BY day;
@@ -0,0 +1,13 @@
+-- name: GetCashAccount :one
+SELECT accounts.* FROM accounts
+JOIN cash_accounts ON cash_accounts.account_id = accounts.id
+WHERE cash_accounts.currency = $1
+LIMIT 1;
+
+-- name: CreateCashAccount :exec
+INSERT INTO cash_accounts (
+    currency,
+    account_id
+) VALUES (
+    $1, $2
+);
@@ -6,6 +6,17 @@ INSERT INTO entries (
     $1, $2
 ) RETURNING *;
 
+-- name: CreateTransferEntry :one
+INSERT INTO entries (
+    account_id,
+    amount,
+    transfer_id,
+    reversed_entry_id,
+    fee
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
 -- name: GetEntry :one
 SELECT * FROM entries
 WHERE id = $1
@@ -13,6 +24,52 @@ LIMIT 1;
 
 -- name: ListEntries :many
 SELECT * FROM entries
-WHERE account_id = $1
-LIMIT $2
-OFFSET $3;
+WHERE account_id = sqlc.arg(account_id)
+  AND (
+    (amount > 0 AND sqlc.arg(incoming)::bool)
+    OR (amount < 0 AND sqlc.arg(outgoing)::bool)
+  )
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (
+    (amount > 0 AND sqlc.arg(incoming)::bool)
+    OR (amount < 0 AND sqlc.arg(outgoing)::bool)
+  )
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransferEntries :many
+SELECT * FROM entries
+WHERE transfer_id = $1
+ORDER BY id;
+
+-- name: ListStatementEntries :many
+SELECT entries.id, entries.amount, entries.created_at, entries.transfer_id, entries.fee,
+    COALESCE(CASE
+        WHEN entries.fee THEN NULL
+        WHEN entries.account_id = transfers.to_account_id THEN transfers.from_account_id
+        WHEN entries.account_id = transfers.from_account_id THEN transfers.to_account_id
+    END, 0)::bigint AS counterparty_account_id,
+    transfers.reversed_transfer_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = sqlc.arg(account_id)
+  AND entries.created_at >= sqlc.arg(created_from)::timestamptz
+  AND entries.created_at < sqlc.arg(created_to)::timestamptz
+  AND (entries.created_at, entries.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY entries.created_at, entries.id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,33 @@
+-- name: CreateFeeSchedule :one
+INSERT INTO fee_schedules (
+    currency,
+    tier,
+    flat_fee,
+    percentage_bps,
+    min_fee,
+    max_fee
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetFeeSchedule :one
+SELECT * FROM fee_schedules
+WHERE currency = sqlc.arg(currency)
+AND (tier = sqlc.arg(tier)::varchar OR tier IS NULL)
+ORDER BY tier IS NULL
+LIMIT 1;
+
+-- name: GetRevenueAccount :one
+SELECT accounts.* FROM accounts
+JOIN revenue_accounts ON revenue_accounts.account_id = accounts.id
+WHERE revenue_accounts.currency = $1
+LIMIT 1;
+
+-- name: CreateRevenueAccount :exec
+INSERT INTO revenue_accounts (
+    currency,
+    account_id
+) VALUES (
+    $1, $2
+);
@@ -0,0 +1,35 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    from_account_id,
+    to_account_id,
+    amount,
+    fee,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SumActiveHolds :one
+SELECT COALESCE(SUM(amount + fee), 0)::bigint AS held_amount FROM holds
+WHERE from_account_id = sqlc.arg(account_id)::bigint
+AND status = 'authorized'
+AND expires_at > now();
+
+-- name: UpdateHold :one
+UPDATE holds
+SET status = $2,
+    transfer_id = $3
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    owner,
+    key,
+    request_path,
+    request_hash
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (owner, key) DO NOTHING
+RETURNING *;
+
+-- name: GetIdempotencyKeyForUpdate :one
+SELECT * FROM idempotency_keys
+WHERE owner = $1 AND key = $2
+LIMIT 1
+FOR UPDATE;
+
+-- name: SetIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_body = $3
+WHERE owner = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE owner = $1 AND key = $2;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- name: CreateRateQuote :one
+INSERT INTO rate_quotes (
+    owner,
+    from_currency,
+    to_currency,
+    rate,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetRateQuote :one
+SELECT * FROM rate_quotes
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetRateQuoteForUpdate :one
+SELECT * FROM rate_quotes
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SetRateQuoteTransfer :exec
+UPDATE rate_quotes
+SET transfer_id = $2
+WHERE id = $1;
\ No newline at end of file
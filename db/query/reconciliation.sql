@@ -0,0 +1,80 @@
+-- name: CountReconciliationTargets :one
+SELECT
+    (SELECT count(*) FROM accounts) AS accounts,
+    (SELECT count(*) FROM transfers) AS transfers;
+
+-- name: CreateReconciliationDiscrepancy :one
+INSERT INTO reconciliation_discrepancies (
+    report_id,
+    kind,
+    account_id,
+    transfer_id,
+    currency,
+    expected,
+    actual
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: CreateReconciliationReport :one
+INSERT INTO reconciliation_reports (
+    accounts_checked,
+    transfers_checked,
+    discrepancies
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetLatestReconciliationReport :one
+SELECT * FROM reconciliation_reports
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAccountBalanceMismatches :many
+SELECT accounts.id, accounts.currency, accounts.balance, COALESCE(sum(entries.amount), 0)::bigint AS entries_total
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+GROUP BY accounts.id
+HAVING accounts.balance <> COALESCE(sum(entries.amount), 0)
+ORDER BY accounts.id;
+
+-- name: ListCurrencyBalanceMismatches :many
+SELECT accounts.currency, sum(entries.amount)::bigint AS entries_total
+FROM entries
+JOIN accounts ON accounts.id = entries.account_id
+GROUP BY accounts.currency
+HAVING sum(entries.amount) <> 0
+ORDER BY accounts.currency;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE report_id = $1
+ORDER BY id;
+
+-- name: ListTransferBalanceMismatches :many
+SELECT entries.transfer_id::bigint AS transfer_id, accounts.currency, sum(entries.amount)::bigint AS entries_total
+FROM entries
+JOIN accounts ON accounts.id = entries.account_id
+WHERE entries.transfer_id IS NOT NULL
+GROUP BY entries.transfer_id, accounts.currency
+HAVING sum(entries.amount) <> 0
+ORDER BY entries.transfer_id, accounts.currency;
+
+-- name: ListTransferEntryMismatches :many
+WITH numbered AS (
+    SELECT transfer_id, account_id, amount,
+        row_number() OVER (PARTITION BY transfer_id ORDER BY id) AS position
+    FROM entries
+    WHERE transfer_id IS NOT NULL
+), matches AS (
+    SELECT transfers.id,
+        ((debit.account_id = transfers.from_account_id AND debit.amount = -transfers.amount) IS TRUE)::int
+        + ((credit.account_id = transfers.to_account_id
+            AND credit.amount = COALESCE(transfers.to_amount, transfers.amount)) IS TRUE)::int AS matching
+    FROM transfers
+    LEFT JOIN numbered debit ON debit.transfer_id = transfers.id AND debit.position = 1
+    LEFT JOIN numbered credit ON credit.transfer_id = transfers.id AND credit.position = 2
+)
+SELECT id, matching::int AS matching FROM matches
+WHERE matching < 2
+ORDER BY id;
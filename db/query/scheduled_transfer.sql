@@ -0,0 +1,69 @@
+-- name: ClaimDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'active'
+AND next_attempt_at <= now()
+ORDER BY next_attempt_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    interval_unit,
+    interval_count,
+    starts_at,
+    next_run_at,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $6, $6
+)
+RETURNING *;
+
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+    scheduled_transfer_id,
+    attempt,
+    transfer_id,
+    error
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE from_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: RescheduleTransfer :one
+UPDATE scheduled_transfers
+SET status = $2,
+    next_run_at = $3,
+    next_attempt_at = $4,
+    attempts = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = COALESCE(sqlc.narg(amount), amount),
+    status = COALESCE(sqlc.narg(status), status)
+WHERE id = sqlc.arg(id)
+AND status = 'active'
+RETURNING *;
\ No newline at end of file
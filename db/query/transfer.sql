@@ -7,13 +7,67 @@ INSERT INTO transfers (
     $1, $2, $3
 ) RETURNING *;
 
+-- name: CreateFxTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    to_amount,
+    exchange_rate,
+    fx_residue
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: CreateReversalTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    reversed_transfer_id
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
-WHERE from_account_id = $1
-LIMIT $2
-OFFSET $3;
\ No newline at end of file
+WHERE (
+    (from_account_id = sqlc.arg(account_id) AND sqlc.arg(outgoing)::bool)
+    OR (to_account_id = sqlc.arg(account_id) AND sqlc.arg(incoming)::bool)
+  )
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListTransfersAfter :many
+SELECT * FROM transfers
+WHERE (
+    (from_account_id = sqlc.arg(account_id) AND sqlc.arg(outgoing)::bool)
+    OR (to_account_id = sqlc.arg(account_id) AND sqlc.arg(incoming)::bool)
+  )
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (created_at, id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: SumTransferReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount FROM transfers
+WHERE reversed_transfer_id = sqlc.arg(transfer_id)::bigint;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+    account_id,
+    currency,
+    period,
+    amount
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE currency = sqlc.arg(currency)
+AND (
+    account_id = sqlc.arg(account_id)
+    OR account_id IS NULL
+)
+ORDER BY id;
+
+-- name: SumAccountTransfers :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS transferred FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)::bigint
+AND created_at >= sqlc.arg(since)::timestamptz
+AND reversed_transfer_id IS NULL;
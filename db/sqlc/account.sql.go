@@ -7,13 +7,14 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const addAmountToAccountBalance = `-- name: AddAmountToAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1 -- equal to: balance + $1
 WHERE id = $2 -- equal to: $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, tier, account_number
 `
 
 type AddAmountToAccountBalanceParams struct {
@@ -30,6 +31,9 @@ func (q *Queries) AddAmountToAccountBalance(ctx context.Context, arg AddAmountTo
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
 	)
 	return i, err
 }
@@ -42,7 +46,7 @@ INSERT INTO accounts (
 ) VALUES (
     $1, $2, $3
 ) 
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, tier, account_number
 `
 
 type CreateAccountParams struct {
@@ -60,12 +64,57 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const createAccountStatusChange = `-- name: CreateAccountStatusChange :one
+INSERT INTO account_status_changes (
+    account_id,
+    from_status,
+    to_status,
+    reason,
+    changed_by
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, account_id, from_status, to_status, reason, changed_by, created_at
+`
+
+type CreateAccountStatusChangeParams struct {
+	AccountID  int64  `db:"account_id" json:"account_id"`
+	FromStatus string `db:"from_status" json:"from_status"`
+	ToStatus   string `db:"to_status" json:"to_status"`
+	Reason     string `db:"reason" json:"reason"`
+	ChangedBy  string `db:"changed_by" json:"changed_by"`
+}
+
+func (q *Queries) CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChanges, error) {
+	row := q.db.QueryRowContext(ctx, createAccountStatusChange,
+		arg.AccountID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.Reason,
+		arg.ChangedBy,
+	)
+	var i AccountStatusChanges
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.Reason,
+		&i.ChangedBy,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, tier, account_number FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -78,12 +127,58 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Accounts, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, status, tier, account_number FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Accounts, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByNumber, accountNumber)
+	var i Accounts
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT id, owner, balance, currency, created_at, status, tier, account_number FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Accounts, error) {
+	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	var i Accounts
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, tier, account_number FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -111,6 +206,61 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.AccountNumber,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, owner, balance, currency, created_at, status, tier, account_number FROM accounts
+WHERE owner = $1
+  AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountsAfterParams struct {
+	Owner          string    `db:"owner" json:"owner"`
+	AfterCreatedAt time.Time `db:"after_created_at" json:"after_created_at"`
+	AfterID        int64     `db:"after_id" json:"after_id"`
+	Limit          int32     `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Accounts, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter,
+		arg.Owner,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Accounts{}
+	for rows.Next() {
+		var i Accounts
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.AccountNumber,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +274,59 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	}
 	return items, nil
 }
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, status, tier, account_number
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `db:"id" json:"id"`
+	Status string `db:"status" json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Accounts, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Accounts
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+const updateAccountTier = `-- name: UpdateAccountTier :one
+UPDATE accounts
+SET tier = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, status, tier, account_number
+`
+
+type UpdateAccountTierParams struct {
+	ID   int64  `db:"id" json:"id"`
+	Tier string `db:"tier" json:"tier"`
+}
+
+func (q *Queries) UpdateAccountTier(ctx context.Context, arg UpdateAccountTierParams) (Accounts, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountTier, arg.ID, arg.Tier)
+	var i Accounts
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.AccountNumber,
+	)
+	return i, err
+}
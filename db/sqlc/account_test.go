@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
@@ -41,6 +42,8 @@ func TestCreateAccount(t *testing.T) {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	// the database generates the account number with the check digits util validates
+	require.True(t, util.IsValidAccountNumber(account.AccountNumber))
 }
 
 func TestGetAccount(t *testing.T) {
@@ -60,6 +63,25 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, account.CreatedAt.Time, retrievedAccount.CreatedAt.Time, time.Second)
 }
 
+func TestGetAccountByNumber(t *testing.T) {
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account, _, err := persistRandomAccount(t, user1, "")
+	require.NoError(t, err)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	other, _, err := persistRandomAccount(t, user2, account.Currency)
+	require.NoError(t, err)
+	require.NotEqual(t, account.AccountNumber, other.AccountNumber)
+
+	retrievedAccount, err := testQueries.GetAccountByNumber(context.Background(), account.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account, retrievedAccount)
+
+	_, err = testQueries.GetAccountByNumber(context.Background(), util.RandomAccountNumber())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestListAccounts(t *testing.T) {
 	user, userArgs, _ := persistRandomUser(t, "ger@gmail.com")
 	fmt.Println("user", user)
@@ -90,3 +112,32 @@ func TestListAccounts(t *testing.T) {
 		require.NotEmpty(t, account)
 	}
 }
+
+func TestListAccountsAfter(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+
+	currencies := []string{util.ARS, util.USD, util.EUR}
+	for _, currency := range currencies {
+		_, _, err := persistRandomAccount(t, user, currency)
+		require.NoError(t, err)
+	}
+
+	arg := ListAccountsAfterParams{
+		Owner: user.Email,
+		Limit: 2,
+	}
+
+	firstPage, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
+
+	// the next page starts after the last account of the previous one
+	last := firstPage[len(firstPage)-1]
+	arg.AfterCreatedAt = last.CreatedAt.Time
+	arg.AfterID = last.ID
+
+	secondPage, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	require.Greater(t, secondPage[0].ID, last.ID)
+}
@@ -0,0 +1,13 @@
+package db
+
+import "github.com/homocode/bank_demo/util"
+
+// RegistryCurrency is the currency as the util.CurrencyRegistry keeps it
+func (c Currencies) RegistryCurrency() util.Currency {
+	return util.Currency{
+		Code:        c.Code,
+		NumericCode: c.NumericCode,
+		Exponent:    c.Exponent,
+		Enabled:     c.Enabled,
+	}
+}
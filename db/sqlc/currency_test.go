@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = currency.Code
+	}
+	require.Subset(t, codes, []string{util.ARS, util.EUR, util.USD})
+
+	usd, err := testQueries.GetCurrency(context.Background(), util.USD)
+	require.NoError(t, err)
+	require.Equal(t, "840", usd.NumericCode)
+	require.Equal(t, int32(2), usd.Exponent)
+}
+
+func TestSetCurrencyEnabled(t *testing.T) {
+	currency, err := testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: util.EUR, Enabled: false})
+	require.NoError(t, err)
+	require.False(t, currency.Enabled)
+	require.False(t, currency.RegistryCurrency().Enabled)
+
+	currency, err = testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: util.EUR, Enabled: true})
+	require.NoError(t, err)
+	require.True(t, currency.Enabled)
+
+	_, err = testQueries.SetCurrencyEnabled(context.Background(), SetCurrencyEnabledParams{Code: "XYZ", Enabled: true})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -15,7 +17,7 @@ INSERT INTO entries (
     amount
 ) VALUES (
     $1, $2
-) RETURNING id, account_id, amount, created_at
+) RETURNING id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee
 `
 
 type CreateEntryParams struct {
@@ -31,12 +33,56 @@ func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entri
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.ReversedEntryID,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const createTransferEntry = `-- name: CreateTransferEntry :one
+INSERT INTO entries (
+    account_id,
+    amount,
+    transfer_id,
+    reversed_entry_id,
+    fee
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee
+`
+
+type CreateTransferEntryParams struct {
+	AccountID       int64         `db:"account_id" json:"account_id"`
+	Amount          int64         `db:"amount" json:"amount"`
+	TransferID      sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	ReversedEntryID sql.NullInt64 `db:"reversed_entry_id" json:"reversed_entry_id"`
+	Fee             bool          `db:"fee" json:"fee"`
+}
+
+func (q *Queries) CreateTransferEntry(ctx context.Context, arg CreateTransferEntryParams) (Entries, error) {
+	row := q.db.QueryRowContext(ctx, createTransferEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.ReversedEntryID,
+		arg.Fee,
+	)
+	var i Entries
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.ReversedEntryID,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee FROM entries
 WHERE id = $1
 LIMIT 1
 `
@@ -49,25 +95,236 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entries, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.ReversedEntryID,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee FROM entries
 WHERE account_id = $1
-LIMIT $2
-OFFSET $3
+  AND (
+    (amount > 0 AND $2::bool)
+    OR (amount < 0 AND $3::bool)
+  )
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR abs(amount) >= $6)
+  AND ($7::bigint IS NULL OR abs(amount) <= $7)
+ORDER BY created_at, id
+LIMIT $8
+OFFSET $9
 `
 
 type ListEntriesParams struct {
-	AccountID int64 `db:"account_id" json:"account_id"`
-	Limit     int32 `db:"limit" json:"limit"`
-	Offset    int32 `db:"offset" json:"offset"`
+	AccountID   int64         `db:"account_id" json:"account_id"`
+	Incoming    bool          `db:"incoming" json:"incoming"`
+	Outgoing    bool          `db:"outgoing" json:"outgoing"`
+	CreatedFrom sql.NullTime  `db:"created_from" json:"created_from"`
+	CreatedTo   sql.NullTime  `db:"created_to" json:"created_to"`
+	MinAmount   sql.NullInt64 `db:"min_amount" json:"min_amount"`
+	MaxAmount   sql.NullInt64 `db:"max_amount" json:"max_amount"`
+	Limit       int32         `db:"limit" json:"limit"`
+	Offset      int32         `db:"offset" json:"offset"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entries, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listEntries,
+		arg.AccountID,
+		arg.Incoming,
+		arg.Outgoing,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entries{}
+	for rows.Next() {
+		var i Entries
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.ReversedEntryID,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesAfter = `-- name: ListEntriesAfter :many
+SELECT id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee FROM entries
+WHERE account_id = $1
+  AND (
+    (amount > 0 AND $2::bool)
+    OR (amount < 0 AND $3::bool)
+  )
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR abs(amount) >= $6)
+  AND ($7::bigint IS NULL OR abs(amount) <= $7)
+  AND (created_at, id) > ($8::timestamptz, $9::bigint)
+ORDER BY created_at, id
+LIMIT $10
+`
+
+type ListEntriesAfterParams struct {
+	AccountID      int64         `db:"account_id" json:"account_id"`
+	Incoming       bool          `db:"incoming" json:"incoming"`
+	Outgoing       bool          `db:"outgoing" json:"outgoing"`
+	CreatedFrom    sql.NullTime  `db:"created_from" json:"created_from"`
+	CreatedTo      sql.NullTime  `db:"created_to" json:"created_to"`
+	MinAmount      sql.NullInt64 `db:"min_amount" json:"min_amount"`
+	MaxAmount      sql.NullInt64 `db:"max_amount" json:"max_amount"`
+	AfterCreatedAt time.Time     `db:"after_created_at" json:"after_created_at"`
+	AfterID        int64         `db:"after_id" json:"after_id"`
+	Limit          int32         `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entries, error) {
+	rows, err := q.db.QueryContext(ctx, listEntriesAfter,
+		arg.AccountID,
+		arg.Incoming,
+		arg.Outgoing,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entries{}
+	for rows.Next() {
+		var i Entries
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.ReversedEntryID,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT entries.id, entries.amount, entries.created_at, entries.transfer_id, entries.fee,
+    COALESCE(CASE
+        WHEN entries.fee THEN NULL
+        WHEN entries.account_id = transfers.to_account_id THEN transfers.from_account_id
+        WHEN entries.account_id = transfers.from_account_id THEN transfers.to_account_id
+    END, 0)::bigint AS counterparty_account_id,
+    transfers.reversed_transfer_id
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE entries.account_id = $1
+  AND entries.created_at >= $2::timestamptz
+  AND entries.created_at < $3::timestamptz
+  AND (entries.created_at, entries.id) > ($4::timestamptz, $5::bigint)
+ORDER BY entries.created_at, entries.id
+LIMIT $6
+`
+
+type ListStatementEntriesParams struct {
+	AccountID      int64     `db:"account_id" json:"account_id"`
+	CreatedFrom    time.Time `db:"created_from" json:"created_from"`
+	CreatedTo      time.Time `db:"created_to" json:"created_to"`
+	AfterCreatedAt time.Time `db:"after_created_at" json:"after_created_at"`
+	AfterID        int64     `db:"after_id" json:"after_id"`
+	Limit          int32     `db:"limit" json:"limit"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64         `db:"id" json:"id"`
+	Amount                int64         `db:"amount" json:"amount"`
+	CreatedAt             sql.NullTime  `db:"created_at" json:"created_at"`
+	TransferID            sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	Fee                   bool          `db:"fee" json:"fee"`
+	CounterpartyAccountID int64         `db:"counterparty_account_id" json:"counterparty_account_id"`
+	ReversedTransferID    sql.NullInt64 `db:"reversed_transfer_id" json:"reversed_transfer_id"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntries,
+		arg.AccountID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Fee,
+			&i.CounterpartyAccountID,
+			&i.ReversedTransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferEntries = `-- name: ListTransferEntries :many
+SELECT id, account_id, amount, created_at, transfer_id, reversed_entry_id, fee FROM entries
+WHERE transfer_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListTransferEntries(ctx context.Context, transferID sql.NullInt64) ([]Entries, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferEntries, transferID)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +337,9 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.ReversedEntryID,
+			&i.Fee,
 		); err != nil {
 			return nil, err
 		}
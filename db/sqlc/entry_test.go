@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -64,6 +65,8 @@ func TestListEntries(t *testing.T) {
 
 	arg := ListEntriesParams{
 		AccountID: testAccount.ID,
+		Incoming:  true,
+		Outgoing:  true,
 		Limit:     int32(n),
 		Offset:    0,
 	}
@@ -80,8 +83,101 @@ func TestListEntries(t *testing.T) {
 
 	require.Len(t, retrievedListEntries, n)
 
-	for _, entry := range retrievedListEntries {
+	for i, entry := range retrievedListEntries {
 		require.NotEmpty(t, entry)
+		if i > 0 {
+			require.Greater(t, entry.ID, retrievedListEntries[i-1].ID)
+		}
 	}
 
 }
+
+func TestListEntriesFilters(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	testAccount, _, _ := persistRandomAccount(t, user, "")
+
+	amounts := []int64{100, -200, 300, -400}
+	for _, amount := range amounts {
+		_, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: testAccount.ID,
+			Amount:    amount,
+		})
+		require.NoError(t, err)
+	}
+
+	arg := ListEntriesParams{
+		AccountID: testAccount.ID,
+		Incoming:  true,
+		Outgoing:  false,
+		Limit:     10,
+	}
+	incoming, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, incoming, 2)
+	for _, entry := range incoming {
+		require.Positive(t, entry.Amount)
+	}
+
+	// the amount range applies to the absolute amount
+	arg = ListEntriesParams{
+		AccountID: testAccount.ID,
+		Incoming:  true,
+		Outgoing:  true,
+		MinAmount: sql.NullInt64{Int64: 200, Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 300, Valid: true},
+		Limit:     10,
+	}
+	inRange, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, inRange, 2)
+	require.Equal(t, int64(-200), inRange[0].Amount)
+	require.Equal(t, int64(300), inRange[1].Amount)
+
+	arg = ListEntriesParams{
+		AccountID:   testAccount.ID,
+		Incoming:    true,
+		Outgoing:    true,
+		CreatedFrom: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:       10,
+	}
+	future, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, future)
+}
+
+func TestListEntriesAfter(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	testAccount, _, _ := persistRandomAccount(t, user, "")
+
+	n := 5
+	for i := 0; i < n; i++ {
+		persistRandomEntry(t, testAccount)
+	}
+
+	arg := ListEntriesAfterParams{
+		AccountID: testAccount.ID,
+		Incoming:  true,
+		Outgoing:  true,
+		Limit:     2,
+	}
+
+	// walk all the pages, every entry must show up once and in order
+	var entries []Entries
+	for {
+		page, err := testQueries.ListEntriesAfter(context.Background(), arg)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		entries = append(entries, page...)
+
+		last := page[len(page)-1]
+		arg.AfterCreatedAt = last.CreatedAt.Time
+		arg.AfterID = last.ID
+	}
+
+	require.Len(t, entries, n)
+	for i := 1; i < n; i++ {
+		require.Greater(t, entries[i].ID, entries[i-1].ID)
+	}
+}
@@ -6,14 +6,60 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// balance of each account at the end of a day, in UTC, so balances in the past don't add up all the entries
+type AccountBalanceSnapshots struct {
+	AccountID int64     `db:"account_id" json:"account_id"`
+	Day       time.Time `db:"day" json:"day"`
+	// sum of the entries of the account created before the day ended
+	Balance   int64     `db:"balance" json:"balance"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
 type Accounts struct {
 	ID        int64        `db:"id" json:"id"`
 	Owner     string       `db:"owner" json:"owner"`
 	Balance   int64        `db:"balance" json:"balance"`
 	Currency  string       `db:"currency" json:"currency"`
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	// active, frozen or closed. Frozen accounts can receive but not send money, closed ones neither
+	Status string `db:"status" json:"status"`
+	// pricing tier, picks the fee schedule of the account
+	Tier string `db:"tier" json:"tier"`
+	// number people use to identify the account, XB, 2 mod-97 check digits and 12 random digits
+	AccountNumber string `db:"account_number" json:"account_number"`
+}
+
+type AccountStatusChanges struct {
+	ID         int64  `db:"id" json:"id"`
+	AccountID  int64  `db:"account_id" json:"account_id"`
+	FromStatus string `db:"from_status" json:"from_status"`
+	ToStatus   string `db:"to_status" json:"to_status"`
+	Reason     string `db:"reason" json:"reason"`
+	// email of the user that changed the status
+	ChangedBy string    `db:"changed_by" json:"changed_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// internal account of each currency, the counterpart of deposits, withdrawals and conversions
+type CashAccounts struct {
+	Currency  string `db:"currency" json:"currency"`
+	AccountID int64  `db:"account_id" json:"account_id"`
+}
+
+// ISO 4217 currencies, accounts and transfers can only be created in the enabled ones
+type Currencies struct {
+	Code        string `db:"code" json:"code"`
+	NumericCode string `db:"numeric_code" json:"numeric_code"`
+	// decimals of the minor unit the amounts are stored in, 2 for cents
+	Exponent  int32     `db:"exponent" json:"exponent"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
 type Entries struct {
@@ -22,6 +68,158 @@ type Entries struct {
 	// can be positive or negative
 	Amount    int64        `db:"amount" json:"amount"`
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	// transfer that booked the entry, null for deposits and withdrawals
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	// entry of the original transfer compensated by this one, set on reversals
+	ReversedEntryID sql.NullInt64 `db:"reversed_entry_id" json:"reversed_entry_id"`
+	// charges or collects the fee of its transfer, the other entries of the transfer move its amount
+	Fee bool `db:"fee" json:"fee"`
+}
+
+// fee charged to the sender of a transfer, flat_fee plus percentage_bps of the amount bounded by min_fee and max_fee
+type FeeSchedules struct {
+	ID       int64  `db:"id" json:"id"`
+	Currency string `db:"currency" json:"currency"`
+	// tier of the accounts charged, null applies to the tiers without a schedule of their own
+	Tier    sql.NullString `db:"tier" json:"tier"`
+	FlatFee int64          `db:"flat_fee" json:"flat_fee"`
+	// basis points of the amount, 100 is 1%
+	PercentageBps int64 `db:"percentage_bps" json:"percentage_bps"`
+	MinFee        int64 `db:"min_fee" json:"min_fee"`
+	// null for no maximum
+	MaxFee    sql.NullInt64 `db:"max_fee" json:"max_fee"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+}
+
+// funds of from_account reserved for a later transfer to to_account
+type Holds struct {
+	ID            int64 `db:"id" json:"id"`
+	FromAccountID int64 `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64 `db:"to_account_id" json:"to_account_id"`
+	// must be positive, the most that can be captured
+	Amount int64 `db:"amount" json:"amount"`
+	// authorized, captured or voided. An authorized hold stops reserving funds at expires_at
+	Status string `db:"status" json:"status"`
+	// transfer that settled the hold, set when captured
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	ExpiresAt  time.Time     `db:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time     `db:"created_at" json:"created_at"`
+	// fee of a transfer of the amount when authorized, reserved with the amount. A capture is charged up to it
+	Fee int64 `db:"fee" json:"fee"`
+}
+
+type IdempotencyKeys struct {
+	Owner       string `db:"owner" json:"owner"`
+	Key         string `db:"key" json:"key"`
+	RequestPath string `db:"request_path" json:"request_path"`
+	// fingerprint of the request payload
+	RequestHash string `db:"request_hash" json:"request_hash"`
+	// result returned to the first request, replayed to the retries
+	ResponseBody json.RawMessage `db:"response_body" json:"response_body"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+}
+
+type RateQuotes struct {
+	ID           int64  `db:"id" json:"id"`
+	Owner        string `db:"owner" json:"owner"`
+	FromCurrency string `db:"from_currency" json:"from_currency"`
+	ToCurrency   string `db:"to_currency" json:"to_currency"`
+	// minor units of to_currency per minor unit of from_currency, scaled by 10^6
+	Rate int64 `db:"rate" json:"rate"`
+	// transfer that used the quote, a quote can be used only once
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	ExpiresAt  time.Time     `db:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time     `db:"created_at" json:"created_at"`
+}
+
+type ReconciliationDiscrepancies struct {
+	ID       int64 `db:"id" json:"id"`
+	ReportID int64 `db:"report_id" json:"report_id"`
+	// account_balance, currency_balance, transfer_entries or transfer_balance
+	Kind string `db:"kind" json:"kind"`
+	// account that doesn't reconcile, set on account_balance
+	AccountID sql.NullInt64 `db:"account_id" json:"account_id"`
+	// transfer that doesn't reconcile, set on transfer_entries and transfer_balance
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	// null on transfer_entries
+	Currency sql.NullString `db:"currency" json:"currency"`
+	// value the invariant requires
+	Expected int64 `db:"expected" json:"expected"`
+	// value found in the ledger
+	Actual int64 `db:"actual" json:"actual"`
+}
+
+// result of a run of the reconciliation of the ledger
+type ReconciliationReports struct {
+	ID               int64 `db:"id" json:"id"`
+	AccountsChecked  int64 `db:"accounts_checked" json:"accounts_checked"`
+	TransfersChecked int64 `db:"transfers_checked" json:"transfers_checked"`
+	// discrepancies found, zero when the ledger reconciles
+	Discrepancies int64     `db:"discrepancies" json:"discrepancies"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// internal account of each currency that collects the fees
+type RevenueAccounts struct {
+	Currency  string `db:"currency" json:"currency"`
+	AccountID int64  `db:"account_id" json:"account_id"`
+}
+
+type ScheduledTransferRuns struct {
+	ID                  int64 `db:"id" json:"id"`
+	ScheduledTransferID int64 `db:"scheduled_transfer_id" json:"scheduled_transfer_id"`
+	Attempt             int32 `db:"attempt" json:"attempt"`
+	// transfer booked by the run, null when it failed
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	// why the run failed, null when it succeeded
+	Error     sql.NullString `db:"error" json:"error"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+type ScheduledTransfers struct {
+	ID            int64 `db:"id" json:"id"`
+	FromAccountID int64 `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64 `db:"to_account_id" json:"to_account_id"`
+	Amount        int64 `db:"amount" json:"amount"`
+	// day, week or month, null for transfers that run once
+	IntervalUnit sql.NullString `db:"interval_unit" json:"interval_unit"`
+	// units between runs, 2 with month runs every other month
+	IntervalCount int32 `db:"interval_count" json:"interval_count"`
+	// active, completed, failed or cancelled. Only active transfers run
+	Status string `db:"status" json:"status"`
+	// first occurrence, the following ones are counted from it
+	StartsAt time.Time `db:"starts_at" json:"starts_at"`
+	// when the next occurrence is due
+	NextRunAt time.Time `db:"next_run_at" json:"next_run_at"`
+	// when the worker picks it up, later than next_run_at while retrying a failed run
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	// failed attempts of the next occurrence
+	Attempts  int32     `db:"attempts" json:"attempts"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type Sessions struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	Email        string    `db:"email" json:"email"`
+	RefreshToken string    `db:"refresh_token" json:"refresh_token"`
+	UserAgent    string    `db:"user_agent" json:"user_agent"`
+	ClientIp     string    `db:"client_ip" json:"client_ip"`
+	IsBlocked    bool      `db:"is_blocked" json:"is_blocked"`
+	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// most that can be transferred out of accounts in a currency, every limit that matches an account applies
+type TransferLimits struct {
+	ID int64 `db:"id" json:"id"`
+	// account limited, null for defaults
+	AccountID sql.NullInt64 `db:"account_id" json:"account_id"`
+	Currency  string        `db:"currency" json:"currency"`
+	// transaction, day or month. Days and months start at midnight UTC
+	Period string `db:"period" json:"period"`
+	// most that can be transferred in the period
+	Amount    int64     `db:"amount" json:"amount"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
 type Transfers struct {
@@ -31,6 +229,14 @@ type Transfers struct {
 	// must be positive
 	Amount    int64        `db:"amount" json:"amount"`
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	// amount credited in the currency of the to account, set on cross-currency transfers
+	ToAmount sql.NullInt64 `db:"to_amount" json:"to_amount"`
+	// applied rate scaled by 10^6, set on cross-currency transfers
+	ExchangeRate sql.NullInt64 `db:"exchange_rate" json:"exchange_rate"`
+	// amount rounded off the conversion scaled by 10^6, set on cross-currency transfers
+	FxResidue sql.NullInt64 `db:"fx_residue" json:"fx_residue"`
+	// transfer refunded by this one, set on reversals
+	ReversedTransferID sql.NullInt64 `db:"reversed_transfer_id" json:"reversed_transfer_id"`
 }
 
 type Users struct {
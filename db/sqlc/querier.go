@@ -6,21 +6,89 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAmountToAccountBalance(ctx context.Context, arg AddAmountToAccountBalanceParams) (Accounts, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Sessions, error)
+	ClaimDueScheduledTransfer(ctx context.Context) (ScheduledTransfers, error)
+	CountReconciliationTargets(ctx context.Context) (CountReconciliationTargetsRow, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Accounts, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChanges, error)
+	CreateBalanceSnapshots(ctx context.Context, day time.Time) (int64, error)
+	CreateCashAccount(ctx context.Context, arg CreateCashAccountParams) error
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entries, error)
+	CreateFeeSchedule(ctx context.Context, arg CreateFeeScheduleParams) (FeeSchedules, error)
+	CreateFxTransfer(ctx context.Context, arg CreateFxTransferParams) (Transfers, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Holds, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKeys, error)
+	CreateRateQuote(ctx context.Context, arg CreateRateQuoteParams) (RateQuotes, error)
+	CreateReconciliationDiscrepancy(ctx context.Context, arg CreateReconciliationDiscrepancyParams) (ReconciliationDiscrepancies, error)
+	CreateReconciliationReport(ctx context.Context, arg CreateReconciliationReportParams) (ReconciliationReports, error)
+	CreateRevenueAccount(ctx context.Context, arg CreateRevenueAccountParams) error
+	CreateReversalTransfer(ctx context.Context, arg CreateReversalTransferParams) (Transfers, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfers, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRuns, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Sessions, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfers, error)
+	CreateTransferEntry(ctx context.Context, arg CreateTransferEntryParams) (Entries, error)
+	CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimits, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (Users, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
 	GetAccount(ctx context.Context, id int64) (Accounts, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Accounts, error)
+	GetAccountForUpdate(ctx context.Context, id int64) (Accounts, error)
+	GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (int64, error)
+	GetCashAccount(ctx context.Context, currency string) (Accounts, error)
+	GetCurrency(ctx context.Context, code string) (Currencies, error)
 	GetEntry(ctx context.Context, id int64) (Entries, error)
+	GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedules, error)
+	GetHold(ctx context.Context, id int64) (Holds, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Holds, error)
+	GetIdempotencyKeyForUpdate(ctx context.Context, arg GetIdempotencyKeyForUpdateParams) (IdempotencyKeys, error)
+	GetLatestReconciliationReport(ctx context.Context) (ReconciliationReports, error)
+	GetRateQuote(ctx context.Context, id int64) (RateQuotes, error)
+	GetRateQuoteForUpdate(ctx context.Context, id int64) (RateQuotes, error)
+	GetRevenueAccount(ctx context.Context, currency string) (Accounts, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfers, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Sessions, error)
 	GetTransfer(ctx context.Context, id int64) (Transfers, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error)
 	GetUser(ctx context.Context, email string) (Users, error)
+	ListAccountBalanceMismatches(ctx context.Context) ([]ListAccountBalanceMismatchesRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Accounts, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Accounts, error)
+	ListBalanceSnapshots(ctx context.Context, arg ListBalanceSnapshotsParams) ([]AccountBalanceSnapshots, error)
+	ListCurrencies(ctx context.Context) ([]Currencies, error)
+	ListCurrencyBalanceMismatches(ctx context.Context) ([]ListCurrencyBalanceMismatchesRow, error)
+	ListDailyEntryTotals(ctx context.Context, arg ListDailyEntryTotalsParams) ([]ListDailyEntryTotalsRow, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entries, error)
+	ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entries, error)
+	ListReconciliationDiscrepancies(ctx context.Context, reportID int64) ([]ReconciliationDiscrepancies, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRuns, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfers, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListTransferBalanceMismatches(ctx context.Context) ([]ListTransferBalanceMismatchesRow, error)
+	ListTransferEntries(ctx context.Context, transferID sql.NullInt64) ([]Entries, error)
+	ListTransferEntryMismatches(ctx context.Context) ([]ListTransferEntryMismatchesRow, error)
+	ListTransferLimits(ctx context.Context, arg ListTransferLimitsParams) ([]TransferLimits, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error)
+	ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfers, error)
+	RescheduleTransfer(ctx context.Context, arg RescheduleTransferParams) (ScheduledTransfers, error)
+	SetCurrencyEnabled(ctx context.Context, arg SetCurrencyEnabledParams) (Currencies, error)
+	SetIdempotencyKeyResponse(ctx context.Context, arg SetIdempotencyKeyResponseParams) error
+	SetRateQuoteTransfer(ctx context.Context, arg SetRateQuoteTransferParams) error
+	SumAccountTransfers(ctx context.Context, arg SumAccountTransfersParams) (int64, error)
+	SumActiveHolds(ctx context.Context, accountID int64) (int64, error)
+	SumTransferReversals(ctx context.Context, transferID int64) (int64, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Accounts, error)
+	UpdateAccountTier(ctx context.Context, arg UpdateAccountTierParams) (Accounts, error)
+	UpdateHold(ctx context.Context, arg UpdateHoldParams) (Holds, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfers, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/fx"
+	"github.com/stretchr/testify/require"
+)
+
+// persistRandomQuote persists a quote of the user from one currency to another, valid for duration
+func persistRandomQuote(t *testing.T, user Users, from string, to string, rate int64, duration time.Duration) (RateQuotes, CreateRateQuoteParams, error) {
+	t.Helper()
+
+	arg := CreateRateQuoteParams{
+		Owner:        user.Email,
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         rate,
+		ExpiresAt:    time.Now().Add(duration),
+	}
+	quote, err := testQueries.CreateRateQuote(context.Background(), arg)
+
+	return quote, arg, err
+}
+
+func TestCreateRateQuote(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	quote, arg, err := persistRandomQuote(t, user, "USD", "ARS", 350*fx.RateScale, time.Minute)
+
+	require.NoError(t, err)
+	require.NotZero(t, quote.ID)
+	require.Equal(t, arg.Owner, quote.Owner)
+	require.Equal(t, arg.FromCurrency, quote.FromCurrency)
+	require.Equal(t, arg.ToCurrency, quote.ToCurrency)
+	require.Equal(t, arg.Rate, quote.Rate)
+	require.False(t, quote.TransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, quote.ExpiresAt, time.Second)
+	require.NotZero(t, quote.CreatedAt)
+}
+
+func TestGetRateQuote(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	quote1, _, _ := persistRandomQuote(t, user, "USD", "ARS", 350*fx.RateScale, time.Minute)
+
+	quote2, err := testQueries.GetRateQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.Owner, quote2.Owner)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+	require.WithinDuration(t, quote1.ExpiresAt, quote2.ExpiresAt, time.Second)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func persistRandomSession(t *testing.T, user Users) (Sessions, CreateSessionParams, error) {
+	t.Helper()
+
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Email:        user.Email,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+
+	return session, arg, err
+}
+
+func TestCreateSession(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	session, arg, err := persistRandomSession(t, user)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Email, session.Email)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+}
+
+func TestGetSession(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	session, _, _ := persistRandomSession(t, user)
+
+	retrievedSession, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, retrievedSession)
+
+	require.Equal(t, session.ID, retrievedSession.ID)
+	require.Equal(t, session.Email, retrievedSession.Email)
+	require.Equal(t, session.RefreshToken, retrievedSession.RefreshToken)
+	require.WithinDuration(t, session.CreatedAt, retrievedSession.CreatedAt, time.Second)
+}
+
+func TestBlockSession(t *testing.T) {
+	user, _, _ := persistRandomUser(t, "")
+	session, _, _ := persistRandomSession(t, user)
+
+	blockedSession, err := testQueries.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, blockedSession.ID)
+	require.True(t, blockedSession.IsBlocked)
+}
@@ -3,26 +3,124 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// txRetries counts the transactions run again by execTx by the name of the error code,
+// and under "exhausted" the ones that still failed after the last retry
+var txRetries = expvar.NewMap("db_tx_retries")
+
+// TxOptions is how execTx runs a transaction
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// MaxRetries is how many times a transaction that failed with a deadlock or
+	// a serialization failure is run again, zero doesn't retry
+	MaxRetries int
+	// Backoff is the wait before the first retry, it doubles on each one and gets up to
+	// as much again of random jitter, so the transactions that collided don't collide again
+	Backoff time.Duration
+}
+
+// TxOption changes the TxOptions of a store or of a single transaction
+type TxOption func(*TxOptions)
+
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+func WithMaxRetries(retries int) TxOption {
+	return func(o *TxOptions) {
+		o.MaxRetries = retries
+	}
+}
+
+func WithBackoff(backoff time.Duration) TxOption {
+	return func(o *TxOptions) {
+		o.Backoff = backoff
+	}
+}
+
+// defaultTxOptions runs the transactions in read committed, the default of Postgres, with a few retries
+var defaultTxOptions = TxOptions{
+	Isolation:  sql.LevelDefault,
+	MaxRetries: 3,
+	Backoff:    10 * time.Millisecond,
+}
+
 // SQLStore provides all the functions to execute SQL queries and transactions
 type SQLStore struct {
 	db *sql.DB
 	*Queries
+	txOptions TxOptions
 }
 
-// NewStore instantiate a new Store
-func NewStore(db *sql.DB) *SQLStore {
+// NewStore instantiate a new Store, opts change the default options of its transactions
+func NewStore(db *sql.DB, opts ...TxOption) *SQLStore {
+	txOptions := defaultTxOptions
+	for _, opt := range opts {
+		opt(&txOptions)
+	}
+
 	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+		db:        db,
+		Queries:   New(db),
+		txOptions: txOptions,
 	}
 }
 
-// execTx executes a function containing a set of queries to perform a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+// execTx executes a function containing a set of queries to perform a database transaction.
+// When the transaction fails with a deadlock or a serialization failure it's rolled back and
+// fn runs again in a new one, so fn must not keep state from a previous attempt.
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error, opts ...TxOption) error {
+	options := store.txOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := store.runTx(ctx, options, fn)
+
+		code, retryable := retryableCode(err)
+		if !retryable {
+			return err
+		}
+		if attempt >= options.MaxRetries {
+			txRetries.Add("exhausted", 1)
+			return err
+		}
+		txRetries.Add(code, 1)
+
+		wait := options.Backoff << attempt
+		wait += time.Duration(rand.Int63n(int64(wait) + 1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runTx runs fn in a transaction, committing it when fn succeeds and rolling it back otherwise
+func (store *SQLStore) runTx(ctx context.Context, options TxOptions, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: options.Isolation,
+		ReadOnly:  options.ReadOnly,
+	})
 	if err != nil {
 		return err
 	}
@@ -31,10 +129,32 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("transaction error: %v, rollback error: %v", err, rbErr)
+			return fmt.Errorf("transaction error: %w, rollback error: %v", err, rbErr)
 		}
 		return err
 	}
 
 	return tx.Commit()
 }
+
+// retryableCode returns the name of the code of err when it's a deadlock or a serialization failure,
+// Postgres aborts one of the transactions involved and running it again is expected to succeed
+func retryableCode(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+
+	switch pqErr.Code {
+	case "40P01", "40001":
+		return pqErr.Code.Name(), true
+	}
+
+	return "", false
+}
+
+// isRetryable reports whether execTx would run again a transaction that failed with err
+func isRetryable(err error) bool {
+	_, retryable := retryableCode(err)
+	return retryable
+}
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// transferInRequestOrder books a transfer locking its accounts in the order of the request instead of by id,
+// so two of them in opposite directions deadlock. ready makes both hold their first lock before taking
+// the second one, only on the first attempt.
+func transferInRequestOrder(store *SQLStore, arg TransferTxParams, ready *sync.WaitGroup, opts ...TxOption) error {
+	attempt := 0
+
+	return store.execTx(context.Background(), func(q *Queries) error {
+		attempt++
+
+		_, err := q.GetAccountForUpdate(context.Background(), arg.FromAccountId)
+		if err != nil {
+			return err
+		}
+
+		if attempt == 1 {
+			ready.Done()
+			ready.Wait()
+		}
+
+		_, err = q.GetAccountForUpdate(context.Background(), arg.ToAccountId)
+		if err != nil {
+			return err
+		}
+
+		_, err = bookTransfer(context.Background(), q, arg)
+		return err
+	}, opts...)
+}
+
+// retryCount is the count of txRetries under key
+func retryCount(key string) int64 {
+	count, ok := txRetries.Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+
+	return count.Value()
+}
+
+func TestExecTxRetriesDeadlock(t *testing.T) {
+	store := NewStore(testDb, WithBackoff(time.Millisecond))
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+	account1 = fundAccount(t, account1, 100)
+	account2 = fundAccount(t, account2, 100)
+
+	deadlocks := retryCount("deadlock_detected")
+
+	var ready sync.WaitGroup
+	ready.Add(2)
+	errs := make(chan error, 2)
+	for _, arg := range []TransferTxParams{
+		{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10},
+		{FromAccountId: account2.ID, ToAccountId: account1.ID, Amount: 10},
+	} {
+		arg := arg
+		go func() {
+			errs <- transferInRequestOrder(store, arg, &ready)
+		}()
+	}
+
+	// Postgres aborts one of them and it succeeds when it's run again
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-errs)
+	}
+	require.Greater(t, retryCount("deadlock_detected"), deadlocks)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestExecTxNoRetries(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "EUR")
+	account1 = fundAccount(t, account1, 100)
+	account2 = fundAccount(t, account2, 100)
+
+	exhausted := retryCount("exhausted")
+
+	var ready sync.WaitGroup
+	ready.Add(2)
+	errs := make(chan error, 2)
+	for _, arg := range []TransferTxParams{
+		{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10},
+		{FromAccountId: account2.ID, ToAccountId: account1.ID, Amount: 10},
+	} {
+		arg := arg
+		go func() {
+			errs <- transferInRequestOrder(store, arg, &ready, WithMaxRetries(0))
+		}()
+	}
+
+	var failed []error
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	// without retries the deadlock reaches the caller
+	require.Len(t, failed, 1)
+	var pqErr *pq.Error
+	require.True(t, errors.As(failed[0], &pqErr))
+	require.Equal(t, pq.ErrorCode("40P01"), pqErr.Code)
+	require.Greater(t, retryCount("exhausted"), exhausted)
+}
+
+func TestExecTxRetriesSerializationFailure(t *testing.T) {
+	store := NewStore(testDb, WithIsolation(sql.LevelSerializable), WithBackoff(time.Millisecond))
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "ARS")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "ARS")
+
+	failures := retryCount("serialization_failure")
+
+	// each transaction reads both accounts and writes one of them, a write skew
+	// that serializable transactions don't allow to commit together
+	var ready sync.WaitGroup
+	ready.Add(2)
+	errs := make(chan error, 2)
+	for _, id := range []int64{account1.ID, account2.ID} {
+		id := id
+		go func() {
+			attempt := 0
+			errs <- store.execTx(context.Background(), func(q *Queries) error {
+				attempt++
+
+				for _, accountID := range []int64{account1.ID, account2.ID} {
+					_, err := q.GetAccount(context.Background(), accountID)
+					if err != nil {
+						return err
+					}
+				}
+
+				if attempt == 1 {
+					ready.Done()
+					ready.Wait()
+				}
+
+				_, err := q.AddAmountToAccountBalance(context.Background(), AddAmountToAccountBalanceParams{
+					Amount: 10,
+					ID:     id,
+				})
+				return err
+			})
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-errs)
+	}
+	require.Greater(t, retryCount("serialization_failure"), failures)
+
+	for _, account := range []Accounts{account1, account2} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance+10, updated.Balance)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{
+			name:      "Deadlock",
+			err:       &pq.Error{Code: "40P01"},
+			retryable: true,
+		},
+		{
+			name:      "SerializationFailure",
+			err:       &pq.Error{Code: "40001"},
+			retryable: true,
+		},
+		{
+			name:      "Wrapped",
+			err:       fmt.Errorf("transaction error: %w, rollback error: %v", &pq.Error{Code: "40P01"}, sql.ErrTxDone),
+			retryable: true,
+		},
+		{
+			name:      "UniqueViolation",
+			err:       &pq.Error{Code: "23505"},
+			retryable: false,
+		},
+		{
+			name:      "NotPostgres",
+			err:       ErrInsufficientFunds,
+			retryable: false,
+		},
+		{
+			name:      "NoError",
+			err:       nil,
+			retryable: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.retryable, isRetryable(tc.err))
+		})
+	}
+}
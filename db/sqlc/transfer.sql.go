@@ -7,8 +7,96 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const createFxTransfer = `-- name: CreateFxTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    to_amount,
+    exchange_rate,
+    fx_residue
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id
+`
+
+type CreateFxTransferParams struct {
+	FromAccountID int64         `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64         `db:"to_account_id" json:"to_account_id"`
+	Amount        int64         `db:"amount" json:"amount"`
+	ToAmount      sql.NullInt64 `db:"to_amount" json:"to_amount"`
+	ExchangeRate  sql.NullInt64 `db:"exchange_rate" json:"exchange_rate"`
+	FxResidue     sql.NullInt64 `db:"fx_residue" json:"fx_residue"`
+}
+
+func (q *Queries) CreateFxTransfer(ctx context.Context, arg CreateFxTransferParams) (Transfers, error) {
+	row := q.db.QueryRowContext(ctx, createFxTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.FxResidue,
+	)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.FxResidue,
+		&i.ReversedTransferID,
+	)
+	return i, err
+}
+
+const createReversalTransfer = `-- name: CreateReversalTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    reversed_transfer_id
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id
+`
+
+type CreateReversalTransferParams struct {
+	FromAccountID      int64         `db:"from_account_id" json:"from_account_id"`
+	ToAccountID        int64         `db:"to_account_id" json:"to_account_id"`
+	Amount             int64         `db:"amount" json:"amount"`
+	ReversedTransferID sql.NullInt64 `db:"reversed_transfer_id" json:"reversed_transfer_id"`
+}
+
+func (q *Queries) CreateReversalTransfer(ctx context.Context, arg CreateReversalTransferParams) (Transfers, error) {
+	row := q.db.QueryRowContext(ctx, createReversalTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ReversedTransferID,
+	)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.FxResidue,
+		&i.ReversedTransferID,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
@@ -16,7 +104,7 @@ INSERT INTO transfers (
     amount
 ) VALUES (
     $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id
 `
 
 type CreateTransferParams struct {
@@ -34,12 +122,16 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.FxResidue,
+		&i.ReversedTransferID,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id FROM transfers
 WHERE id = $1
 LIMIT 1
 `
@@ -53,25 +145,149 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfers, error)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.FxResidue,
+		&i.ReversedTransferID,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.FxResidue,
+		&i.ReversedTransferID,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-WHERE from_account_id = $1
-LIMIT $2
-OFFSET $3
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id FROM transfers
+WHERE (
+    (from_account_id = $1 AND $2::bool)
+    OR (to_account_id = $1 AND $3::bool)
+  )
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR amount >= $6)
+  AND ($7::bigint IS NULL OR amount <= $7)
+ORDER BY created_at, id
+LIMIT $8
+OFFSET $9
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `db:"from_account_id" json:"from_account_id"`
-	Limit         int32 `db:"limit" json:"limit"`
-	Offset        int32 `db:"offset" json:"offset"`
+	AccountID   int64         `db:"account_id" json:"account_id"`
+	Outgoing    bool          `db:"outgoing" json:"outgoing"`
+	Incoming    bool          `db:"incoming" json:"incoming"`
+	CreatedFrom sql.NullTime  `db:"created_from" json:"created_from"`
+	CreatedTo   sql.NullTime  `db:"created_to" json:"created_to"`
+	MinAmount   sql.NullInt64 `db:"min_amount" json:"min_amount"`
+	MaxAmount   sql.NullInt64 `db:"max_amount" json:"max_amount"`
+	Limit       int32         `db:"limit" json:"limit"`
+	Offset      int32         `db:"offset" json:"offset"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.FromAccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listTransfers,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfers{}
+	for rows.Next() {
+		var i Transfers
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.FxResidue,
+			&i.ReversedTransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersAfter = `-- name: ListTransfersAfter :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, fx_residue, reversed_transfer_id FROM transfers
+WHERE (
+    (from_account_id = $1 AND $2::bool)
+    OR (to_account_id = $1 AND $3::bool)
+  )
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR amount >= $6)
+  AND ($7::bigint IS NULL OR amount <= $7)
+  AND (created_at, id) > ($8::timestamptz, $9::bigint)
+ORDER BY created_at, id
+LIMIT $10
+`
+
+type ListTransfersAfterParams struct {
+	AccountID      int64         `db:"account_id" json:"account_id"`
+	Outgoing       bool          `db:"outgoing" json:"outgoing"`
+	Incoming       bool          `db:"incoming" json:"incoming"`
+	CreatedFrom    sql.NullTime  `db:"created_from" json:"created_from"`
+	CreatedTo      sql.NullTime  `db:"created_to" json:"created_to"`
+	MinAmount      sql.NullInt64 `db:"min_amount" json:"min_amount"`
+	MaxAmount      sql.NullInt64 `db:"max_amount" json:"max_amount"`
+	AfterCreatedAt time.Time     `db:"after_created_at" json:"after_created_at"`
+	AfterID        int64         `db:"after_id" json:"after_id"`
+	Limit          int32         `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfers, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersAfter,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +301,10 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.FxResidue,
+			&i.ReversedTransferID,
 		); err != nil {
 			return nil, err
 		}
@@ -98,3 +318,15 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const sumTransferReversals = `-- name: SumTransferReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount FROM transfers
+WHERE reversed_transfer_id = $1::bigint
+`
+
+func (q *Queries) SumTransferReversals(ctx context.Context, transferID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumTransferReversals, transferID)
+	var reversed_amount int64
+	err := row.Scan(&reversed_amount)
+	return reversed_amount, err
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -70,27 +71,106 @@ func TestListTransfer(t *testing.T) {
 	user2, _, _ := persistRandomUser(t, "")
 	account2, _, _ := persistRandomAccount(t, user2, "")
 
-	var fromAccountId int64
-	n := 10
+	n := 5
 
 	for i := 0; i < n; i++ {
-		transfer, _, _ := persistRandomTransfer(t, account1, account2)
-		fromAccountId = transfer.FromAccountID
+		persistRandomTransfer(t, account1, account2)
+		persistRandomTransfer(t, account2, account1)
 	}
 
 	arg := ListTransfersParams{
-		FromAccountID: fromAccountId,
-		Limit:         int32(n),
-		Offset:        0,
+		AccountID: account1.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Limit:     int32(2 * n),
+		Offset:    0,
 	}
 
 	retrievedListTransfer, err := testQueries.ListTransfers(context.Background(), arg)
 
 	require.NoError(t, err)
 
-	require.Len(t, retrievedListTransfer, n)
+	require.Len(t, retrievedListTransfer, 2*n)
 
-	for _, transfer := range retrievedListTransfer {
+	for i, transfer := range retrievedListTransfer {
 		require.NotEmpty(t, transfer)
+		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
+		if i > 0 {
+			require.Greater(t, transfer.ID, retrievedListTransfer[i-1].ID)
+		}
+	}
+
+	// only the incoming ones
+	arg.Outgoing = false
+	retrievedListTransfer, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, retrievedListTransfer, n)
+	for _, transfer := range retrievedListTransfer {
+		require.Equal(t, account1.ID, transfer.ToAccountID)
+	}
+}
+
+func TestListTransferAmountRange(t *testing.T) {
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "")
+
+	for _, amount := range []int64{10, 20, 30} {
+		_, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+	}
+
+	arg := ListTransfersParams{
+		AccountID:   account1.ID,
+		Outgoing:    true,
+		MinAmount:   sql.NullInt64{Int64: 15, Valid: true},
+		MaxAmount:   sql.NullInt64{Int64: 30, Valid: true},
+		CreatedFrom: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		CreatedTo:   sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:       10,
 	}
+
+	transfers, err := testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, int64(20), transfers[0].Amount)
+	require.Equal(t, int64(30), transfers[1].Amount)
+}
+
+func TestListTransfersAfter(t *testing.T) {
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "")
+
+	n := 3
+	for i := 0; i < n; i++ {
+		persistRandomTransfer(t, account1, account2)
+		persistRandomTransfer(t, account2, account1)
+	}
+
+	arg := ListTransfersAfterParams{
+		AccountID: account1.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Limit:     int32(n),
+	}
+
+	firstPage, err := testQueries.ListTransfersAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, firstPage, n)
+
+	last := firstPage[len(firstPage)-1]
+	arg.AfterCreatedAt = last.CreatedAt.Time
+	arg.AfterID = last.ID
+
+	secondPage, err := testQueries.ListTransfersAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, secondPage, n)
+	require.Greater(t, secondPage[0].ID, last.ID)
 }
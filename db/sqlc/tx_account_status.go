@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Status of an account. Frozen accounts can receive money but not send it, closed ones can do neither.
+// An account can be frozen and unfrozen any number of times, closing it is final.
+const (
+	AccountActive = "active"
+	AccountFrozen = "frozen"
+	AccountClosed = "closed"
+)
+
+// Different types of error returned when the status of an account doesn't allow an operation
+var (
+	ErrAccountFrozen           = errors.New("account is frozen")
+	ErrAccountClosed           = errors.New("account is closed")
+	ErrAccountNotEmpty         = errors.New("account is not empty")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+)
+
+type ChangeAccountStatusTxParams struct {
+	AccountID int64
+	// Status is AccountActive or AccountFrozen, accounts are closed with CloseAccountTx
+	Status    string
+	Reason    string
+	ChangedBy string
+}
+
+type CloseAccountTxParams struct {
+	AccountID int64
+	// SweepToAccountID receives the balance left in the account, of any owner, zero when it must be empty
+	SweepToAccountID int64
+	Reason           string
+	ChangedBy        string
+}
+
+type CloseAccountTxResult struct {
+	Account Accounts
+	// Sweep is the transfer of the balance left, empty when there was none
+	Sweep TransferTxResult
+}
+
+// ChangeAccountStatusTx freezes or unfreezes an account and records why
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Accounts, error) {
+	var account Accounts
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountClosed {
+			return fmt.Errorf("%w: account %d", ErrAccountClosed, account.ID)
+		}
+		if (arg.Status != AccountActive && arg.Status != AccountFrozen) || arg.Status == account.Status {
+			return fmt.Errorf("%w: account %d from %s to %s", ErrInvalidStatusTransition, account.ID, account.Status, arg.Status)
+		}
+
+		account, err = setAccountStatus(ctx, q, account, arg.Status, arg.Reason, arg.ChangedBy)
+		return err
+	})
+
+	return account, err
+}
+
+// CloseAccountTx closes an account for good. The account must be empty, unless SweepToAccountID is set,
+// then its balance is transferred there first. The sweep is a transfer like any other, within the limits
+// of the account, but without a fee as there's nothing left to pay it with. A frozen account can only be
+// closed when empty. Accounts with active holds can't be closed until the holds are captured, voided or expire.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// a retried transaction starts over
+		result = CloseAccountTxResult{}
+
+		var account Accounts
+		var sweepTo Accounts
+		var err error
+		if arg.SweepToAccountID != 0 {
+			account, sweepTo, err = lockAccounts(ctx, q, arg.AccountID, arg.SweepToAccountID)
+		} else {
+			account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountClosed {
+			return fmt.Errorf("%w: account %d", ErrAccountClosed, account.ID)
+		}
+
+		held, err := q.SumActiveHolds(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if held != 0 {
+			return fmt.Errorf("%w: account %d has %d in active holds", ErrAccountNotEmpty, account.ID, held)
+		}
+
+		if account.Balance != 0 {
+			if arg.SweepToAccountID == 0 {
+				return fmt.Errorf("%w: account %d has balance %d", ErrAccountNotEmpty, account.ID, account.Balance)
+			}
+
+			// the freeze holds the money where it is
+			err = checkStatus(account, sweepTo)
+			if err != nil {
+				return err
+			}
+			if sweepTo.Currency != account.Currency {
+				return fmt.Errorf("account %d is in %s, sweep account %d in %s",
+					account.ID, account.Currency, sweepTo.ID, sweepTo.Currency)
+			}
+			err = checkLimits(ctx, q, account, account.Balance)
+			if err != nil {
+				return err
+			}
+
+			result.Sweep, err = bookTransfer(ctx, q, TransferTxParams{
+				FromAccountId: account.ID,
+				ToAccountId:   sweepTo.ID,
+				Amount:        account.Balance,
+			})
+			if err != nil {
+				return err
+			}
+			account = result.Sweep.FromAccount
+		}
+
+		result.Account, err = setAccountStatus(ctx, q, account, AccountClosed, arg.Reason, arg.ChangedBy)
+		return err
+	})
+
+	return result, err
+}
+
+// setAccountStatus sets the status of the locked account and records the change
+func setAccountStatus(ctx context.Context, q *Queries, account Accounts, status string, reason string, changedBy string) (Accounts, error) {
+	_, err := q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+		AccountID:  account.ID,
+		FromStatus: account.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ChangedBy:  changedBy,
+	})
+	if err != nil {
+		return account, err
+	}
+
+	return q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: status,
+	})
+}
+
+// checkStatus checks the money can move from fromAccount to toAccount
+func checkStatus(fromAccount Accounts, toAccount Accounts) error {
+	err := checkCanSend(fromAccount)
+	if err != nil {
+		return err
+	}
+
+	return checkCanReceive(toAccount)
+}
+
+// checkCanSend fails with ErrAccountFrozen or ErrAccountClosed unless the account is active
+func checkCanSend(account Accounts) error {
+	switch account.Status {
+	case AccountFrozen:
+		return fmt.Errorf("%w: account %d can't send money", ErrAccountFrozen, account.ID)
+	case AccountClosed:
+		return fmt.Errorf("%w: account %d can't send money", ErrAccountClosed, account.ID)
+	}
+
+	return nil
+}
+
+// checkCanReceive fails with ErrAccountClosed when the account is closed
+func checkCanReceive(account Accounts) error {
+	if account.Status == AccountClosed {
+		return fmt.Errorf("%w: account %d can't receive money", ErrAccountClosed, account.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeAccountStatusTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+	require.Equal(t, AccountActive, account1.Status)
+
+	account1, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status:    AccountFrozen,
+		Reason:    "lost card",
+		ChangedBy: user1.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountFrozen, account1.Status)
+
+	// a frozen account can't send money
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// but it still receives
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account2.ID,
+		ToAccountId:   account1.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+
+	// freezing it twice isn't a transition
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status:    AccountFrozen,
+		Reason:    "lost card",
+		ChangedBy: user1.Email,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	account1, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status:    AccountActive,
+		Reason:    "card found",
+		ChangedBy: user1.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountActive, account1.Status)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "EUR")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 100)
+
+	// an owner has a single account in each currency, the balance goes to someone else's
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "EUR")
+	require.NoError(t, err)
+
+	// an account with balance can't be closed without a sweep
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID: account1.ID,
+		Reason:    "moving abroad",
+		ChangedBy: user1.Email,
+	})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account1.ID,
+		SweepToAccountID: account2.ID,
+		Reason:           "moving abroad",
+		ChangedBy:        user1.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+	require.Equal(t, account1.Balance, result.Sweep.Transfer.Amount)
+	require.Equal(t, account2.Balance+account1.Balance, result.Sweep.ToAccount.Balance)
+
+	// the sweep is charged no fee
+	entries, err := testQueries.ListTransferEntries(context.Background(), sql.NullInt64{Int64: result.Sweep.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// a closed account neither receives nor reopens
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account1.ID,
+		Amount:    10,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status:    AccountActive,
+		Reason:    "changed my mind",
+		ChangedBy: user1.Email,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// nor takes the balance of another account
+	account3, _, err := persistRandomAccount(t, user2, "USD")
+	require.NoError(t, err)
+	user3, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account4, _, err := persistRandomAccount(t, user3, "EUR")
+	require.NoError(t, err)
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account4.ID,
+		SweepToAccountID: account1.ID,
+		Reason:           "moving abroad",
+		ChangedBy:        user3.Email,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// and the sweep stays in the currency
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account4.ID,
+		SweepToAccountID: account3.ID,
+		Reason:           "moving abroad",
+		ChangedBy:        user3.Email,
+	})
+	require.Error(t, err)
+}
+
+func TestCloseAccountTxSweepLimit(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "ARS")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 100)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "ARS")
+	require.NoError(t, err)
+
+	// closing doesn't move more than a transfer could
+	persistTransferLimit(t, CreateTransferLimitParams{
+		AccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		Currency:  "ARS",
+		Period:    LimitPerTransaction,
+		Amount:    account1.Balance - 1,
+	})
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account1.ID,
+		SweepToAccountID: account2.ID,
+		Reason:           "moving abroad",
+		ChangedBy:        user1.Email,
+	})
+	require.ErrorIs(t, err, ErrLimitExceeded)
+
+	got, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountActive, got.Status)
+	require.Equal(t, account1.Balance, got.Balance)
+}
+
+func TestCloseFrozenAccountTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "EUR")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 100)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "EUR")
+	require.NoError(t, err)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status:    AccountFrozen,
+		Reason:    "suspicious activity",
+		ChangedBy: user1.Email,
+	})
+	require.NoError(t, err)
+
+	// closing it can't move the frozen balance out
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account1.ID,
+		SweepToAccountID: account2.ID,
+		Reason:           "moving abroad",
+		ChangedBy:        user1.Email,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	got, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountFrozen, got.Status)
+	require.Equal(t, account1.Balance, got.Balance)
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DailyBalance is the balance of an account at the end of a day
+type DailyBalance struct {
+	Day     time.Time `json:"day"`
+	Balance int64     `json:"balance"`
+}
+
+// BalanceHistory lists the balance of the account at the end of each day from fromDay to toDay,
+// both days at midnight in UTC. The days with a snapshot take its balance and the entries are only
+// added up from the first day without one, usually the last days of the range.
+func (store *SQLStore) BalanceHistory(ctx context.Context, accountID int64, fromDay time.Time, toDay time.Time) ([]DailyBalance, error) {
+	var history []DailyBalance
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		history, err = balanceHistory(ctx, q, accountID, fromDay, toDay)
+		return err
+	}, WithIsolation(sql.LevelRepeatableRead), WithReadOnly())
+
+	return history, err
+}
+
+func balanceHistory(ctx context.Context, q *Queries, accountID int64, fromDay time.Time, toDay time.Time) ([]DailyBalance, error) {
+	snapshots, err := q.ListBalanceSnapshots(ctx, ListBalanceSnapshotsParams{
+		AccountID: accountID,
+		FromDay:   fromDay,
+		ToDay:     toDay,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history := []DailyBalance{}
+	day := fromDay
+	for _, snapshot := range snapshots {
+		if day.After(toDay) || !snapshot.Day.Equal(day) {
+			break
+		}
+		history = append(history, DailyBalance{Day: day, Balance: snapshot.Balance})
+		day = day.AddDate(0, 0, 1)
+	}
+	if day.After(toDay) {
+		return history, nil
+	}
+
+	// from the first day without a snapshot, the balance at its start plus the entries of each day
+	balance, err := q.GetBalanceAt(ctx, GetBalanceAtParams{
+		AccountID: accountID,
+		At:        day,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := q.ListDailyEntryTotals(ctx, ListDailyEntryTotalsParams{
+		AccountID:   accountID,
+		CreatedFrom: day,
+		CreatedTo:   toDay.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for ; !day.After(toDay); day = day.AddDate(0, 0, 1) {
+		if len(totals) > 0 && totals[0].Day.Equal(day) {
+			balance += totals[0].Total
+			totals = totals[1:]
+		}
+		history = append(history, DailyBalance{Day: day, Balance: balance})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceAt(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user, "USD")
+
+	_, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 100})
+	require.NoError(t, err)
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 30})
+	require.NoError(t, err)
+
+	// the balance is made of the entries, the test accounts are created with a balance and no entries
+	balance, err := testQueries.GetBalanceAt(context.Background(), GetBalanceAtParams{
+		AccountID: account.ID,
+		At:        time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, balance)
+
+	balance, err = testQueries.GetBalanceAt(context.Background(), GetBalanceAtParams{
+		AccountID: account.ID,
+		At:        time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), balance)
+}
+
+func TestBalanceHistory(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user, "EUR")
+
+	_, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 100})
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	history, err := store.BalanceHistory(context.Background(), account.ID, yesterday, tomorrow)
+	require.NoError(t, err)
+	require.Equal(t, []DailyBalance{
+		{Day: yesterday, Balance: 0},
+		{Day: today, Balance: 100},
+		{Day: tomorrow, Balance: 100},
+	}, history)
+
+	_, err = testQueries.CreateBalanceSnapshots(context.Background(), today)
+	require.NoError(t, err)
+
+	snapshots, err := testQueries.ListBalanceSnapshots(context.Background(), ListBalanceSnapshotsParams{
+		AccountID: account.ID,
+		FromDay:   yesterday,
+		ToDay:     tomorrow,
+	})
+	require.NoError(t, err)
+	// the account didn't exist yesterday
+	require.Len(t, snapshots, 1)
+	require.True(t, today.Equal(snapshots[0].Day))
+	require.Equal(t, int64(100), snapshots[0].Balance)
+
+	// today comes from the snapshot and tomorrow starts from it
+	history, err = store.BalanceHistory(context.Background(), account.ID, today, tomorrow)
+	require.NoError(t, err)
+	require.Equal(t, []DailyBalance{
+		{Day: today, Balance: 100},
+		{Day: tomorrow, Balance: 100},
+	}, history)
+
+	// snapshotting the day again doesn't change it
+	_, err = testQueries.CreateBalanceSnapshots(context.Background(), today)
+	require.NoError(t, err)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// BatchTransferError is returned by BatchTransferTx with the error of the first transfer that failed
+type BatchTransferError struct {
+	// Index of the transfer in the batch
+	Index int
+	Err   error
+}
+
+func (e *BatchTransferError) Error() string {
+	return fmt.Sprintf("transfer %d of the batch: %v", e.Index, e.Err)
+}
+
+func (e *BatchTransferError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransferTx books all the transfers in a single transaction, in order, so a transfer sees the
+// balances left by the previous ones. When one fails none is booked and the error is a *BatchTransferError.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, transfers []TransferTxParams) ([]TransferTxResult, error) {
+	results := make([]TransferTxResult, len(transfers))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := lockBatchAccounts(ctx, q, transfers)
+		if err != nil {
+			return err
+		}
+
+		for i, arg := range transfers {
+			results[i], err = transferTx(ctx, q, arg)
+			if err != nil {
+				return &BatchTransferError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// lockBatchAccounts selects for update every account of the transfers, in order of id like lockAccounts
+// does for two. Each transfer locks its accounts again, which doesn't wait on rows the transaction holds,
+// so concurrent batches and transfers always lock in the same order and can't deadlock.
+func lockBatchAccounts(ctx context.Context, q *Queries, transfers []TransferTxParams) error {
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0, 2*len(transfers))
+	for _, arg := range transfers {
+		for _, id := range []int64{arg.FromAccountId, arg.ToAccountId} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		_, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
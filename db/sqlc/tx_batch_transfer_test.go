@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user2, "USD")
+	user3, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user3, "USD")
+	account = fundAccount(t, account, 100)
+
+	results, err := store.BatchTransferTx(context.Background(), []TransferTxParams{
+		{FromAccountId: account.ID, ToAccountId: account1.ID, Amount: 30},
+		{FromAccountId: account.ID, ToAccountId: account2.ID, Amount: 40},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// each transfer sees the balance left by the previous one
+	require.Equal(t, account.Balance-30, results[0].FromAccount.Balance)
+	require.Equal(t, account.Balance-70, results[1].FromAccount.Balance)
+	require.Equal(t, account1.Balance+30, results[0].ToAccount.Balance)
+	require.Equal(t, account2.Balance+40, results[1].ToAccount.Balance)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user2, "EUR")
+
+	// the second transfer can't be afforded after the first
+	_, err := store.BatchTransferTx(context.Background(), []TransferTxParams{
+		{FromAccountId: account.ID, ToAccountId: account1.ID, Amount: account.Balance},
+		{FromAccountId: account.ID, ToAccountId: account1.ID, Amount: 1},
+	})
+	var batchErr *BatchTransferError
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, 1, batchErr.Index)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the first one was rolled back
+	got, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, got.Balance)
+}
+
+func TestBatchTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDb)
+
+	accounts := make([]Accounts, 4)
+	for i := range accounts {
+		user, _, _ := persistRandomUser(t, "")
+		accounts[i], _, _ = persistRandomAccount(t, user, "ARS")
+		accounts[i] = fundAccount(t, accounts[i], 100)
+	}
+
+	// batches that touch the same accounts in opposite orders would deadlock without the lock ordering
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		batch := []TransferTxParams{
+			{FromAccountId: accounts[0].ID, ToAccountId: accounts[3].ID, Amount: 1},
+			{FromAccountId: accounts[2].ID, ToAccountId: accounts[1].ID, Amount: 1},
+		}
+		if i%2 == 1 {
+			batch = []TransferTxParams{
+				{FromAccountId: accounts[3].ID, ToAccountId: accounts[0].ID, Amount: 1},
+				{FromAccountId: accounts[1].ID, ToAccountId: accounts[2].ID, Amount: 1},
+			}
+		}
+
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), batch)
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	// as many batches went each way, the balances are back where they started
+	for _, account := range accounts {
+		got, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, got.Balance)
+	}
+}
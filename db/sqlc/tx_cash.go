@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrCashAccountNotFound is returned when there is no cash account for the currency of an operation
+var ErrCashAccountNotFound = errors.New("cash account not found")
+
+type CashTxParams struct {
+	AccountID int64
+	// Amount is always positive, DepositTx adds it to the account and WithdrawTx subtracts it
+	Amount int64
+}
+
+type CashTxResult struct {
+	Account     Accounts
+	Entry       Entries
+	CashAccount Accounts
+	CashEntry   Entries
+}
+
+// DepositTx adds money to the account, balanced by an entry that takes it out of the cash account
+// of the account currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = cashTx(ctx, q, arg.AccountID, arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+// WithdrawTx takes money out of the account into the cash account of the account currency.
+// It fails with ErrInsufficientFunds when the available balance doesn't cover the amount.
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = cashTx(ctx, q, arg.AccountID, -arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+// cashTx moves amount between the account and its cash account, a positive amount is a deposit
+// and a negative one a withdrawal. Both entries add up to zero.
+func cashTx(ctx context.Context, q *Queries, accountID int64, amount int64) (result CashTxResult, err error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return
+	}
+
+	cashAccount, err := cashAccountOf(ctx, q, account.Currency)
+	if err != nil {
+		return
+	}
+	if cashAccount.ID == account.ID {
+		err = fmt.Errorf("account %d is a cash account", account.ID)
+		return
+	}
+
+	// Every transaction locks the customer accounts it touches before the cash accounts.
+	// The cash accounts are shared by all the operations in a currency, with that order
+	// they never take part in a deadlock.
+	account, err = q.GetAccountForUpdate(ctx, account.ID)
+	if err != nil {
+		return
+	}
+	_, err = q.GetAccountForUpdate(ctx, cashAccount.ID)
+	if err != nil {
+		return
+	}
+
+	if amount < 0 {
+		err = checkCanSend(account)
+		if err != nil {
+			return
+		}
+
+		err = checkFunds(ctx, q, account, -amount, "withdrawal")
+		if err != nil {
+			return
+		}
+	} else {
+		err = checkCanReceive(account)
+		if err != nil {
+			return
+		}
+	}
+
+	result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.CashEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: cashAccount.ID,
+		Amount:    -amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.Account, result.CashAccount, err = addMoney(ctx, q, account.ID, amount, cashAccount.ID, -amount)
+	return
+}
+
+// cashAccountOf returns the cash account of the currency, wrapping ErrCashAccountNotFound when there is none
+func cashAccountOf(ctx context.Context, q *Queries, currency string) (Accounts, error) {
+	cashAccount, err := q.GetCashAccount(ctx, currency)
+	if err == sql.ErrNoRows {
+		return cashAccount, fmt.Errorf("%w: %s", ErrCashAccountNotFound, currency)
+	}
+
+	return cashAccount, err
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user, "USD")
+	cashAccount, err := testQueries.GetCashAccount(context.Background(), "USD")
+	require.NoError(t, err)
+
+	amount := int64(50)
+	n := 5
+
+	errs := make(chan error, n)
+	results := make(chan CashTxResult, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			result, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: amount})
+			results <- result
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(results)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for result := range results {
+		require.Equal(t, account.ID, result.Entry.AccountID)
+		require.Equal(t, amount, result.Entry.Amount)
+		require.Equal(t, cashAccount.ID, result.CashEntry.AccountID)
+		// the entries of the operation add up to zero
+		require.Zero(t, result.Entry.Amount+result.CashEntry.Amount)
+	}
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+amount*int64(n), updatedAccount.Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	account, _, _ := persistRandomAccount(t, user, "EUR")
+	cashAccount, err := testQueries.GetCashAccount(context.Background(), "EUR")
+	require.NoError(t, err)
+
+	result, err := store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: account.Balance})
+	require.NoError(t, err)
+	require.Zero(t, result.Account.Balance)
+	require.Equal(t, -account.Balance, result.Entry.Amount)
+	require.Equal(t, cashAccount.ID, result.CashAccount.ID)
+	require.Equal(t, account.Balance, result.CashEntry.Amount)
+
+	// the account is never overdrawn
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 1})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// The system users that own the internal accounts of each currency, created by the migrations
+const (
+	CashOwner    = "cash@system.internal"
+	RevenueOwner = "revenue@system.internal"
+)
+
+// EnableCurrencyTx enables the currency and creates its cash and revenue accounts when it doesn't
+// have them yet, so the deposits, withdrawals and fees in the currency have their counterpart.
+// It fails with sql.ErrNoRows when the currency doesn't exist.
+func (store *SQLStore) EnableCurrencyTx(ctx context.Context, code string) (Currencies, error) {
+	var currency Currencies
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		currency, err = q.SetCurrencyEnabled(ctx, SetCurrencyEnabledParams{
+			Code:    code,
+			Enabled: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetCashAccount(ctx, code)
+		if err == sql.ErrNoRows {
+			err = createSystemAccount(ctx, q, CashOwner, code, func(accountID int64) error {
+				return q.CreateCashAccount(ctx, CreateCashAccountParams{Currency: code, AccountID: accountID})
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetRevenueAccount(ctx, code)
+		if err == sql.ErrNoRows {
+			err = createSystemAccount(ctx, q, RevenueOwner, code, func(accountID int64) error {
+				return q.CreateRevenueAccount(ctx, CreateRevenueAccountParams{Currency: code, AccountID: accountID})
+			})
+		}
+		return err
+	})
+
+	return currency, err
+}
+
+// createSystemAccount creates the account of the system user in the currency and registers it with register
+func createSystemAccount(ctx context.Context, q *Queries, owner string, currency string, register func(accountID int64) error) error {
+	account, err := q.CreateAccount(ctx, CreateAccountParams{
+		Owner:    owner,
+		Balance:  0,
+		Currency: currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	return register(account.ID)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableCurrencyTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	// a currency added disabled, without the system accounts the migrations made for the first ones
+	_, err := testDb.Exec(`INSERT INTO currencies (code, numeric_code, exponent, enabled) VALUES ('CHF', '756', 2, false)
+		ON CONFLICT (code) DO NOTHING`)
+	require.NoError(t, err)
+
+	currency, err := store.EnableCurrencyTx(context.Background(), "CHF")
+	require.NoError(t, err)
+	require.True(t, currency.Enabled)
+
+	cashAccount, err := testQueries.GetCashAccount(context.Background(), "CHF")
+	require.NoError(t, err)
+	require.Equal(t, CashOwner, cashAccount.Owner)
+
+	revenueAccount, err := testQueries.GetRevenueAccount(context.Background(), "CHF")
+	require.NoError(t, err)
+	require.Equal(t, RevenueOwner, revenueAccount.Owner)
+
+	// enabling it again keeps the accounts it has
+	_, err = store.EnableCurrencyTx(context.Background(), "CHF")
+	require.NoError(t, err)
+
+	got, err := testQueries.GetCashAccount(context.Background(), "CHF")
+	require.NoError(t, err)
+	require.Equal(t, cashAccount.ID, got.ID)
+
+	_, err = store.EnableCurrencyTx(context.Background(), "XYZ")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
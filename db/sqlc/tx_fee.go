@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/homocode/bank_demo/util"
+)
+
+// ErrRevenueAccountNotFound is returned when a transfer has a fee but there is no revenue account
+// for the currency to collect it
+var ErrRevenueAccountNotFound = errors.New("revenue account not found")
+
+// AccountTierStandard is the tier of the accounts unless they're moved to another one
+const AccountTierStandard = "standard"
+
+// bpsScale is the basis points in a whole, 10000 bps are 100%
+const bpsScale = 10000
+
+// TransferFee is the fee a transfer of amount out of the account would be charged
+func (store *SQLStore) TransferFee(ctx context.Context, account Accounts, amount int64) (int64, error) {
+	return transferFee(ctx, store.Queries, account, amount)
+}
+
+// transferFee gets the fee schedule of the tier and currency of the account and applies it to amount,
+// without a schedule there is no fee
+func transferFee(ctx context.Context, q *Queries, account Accounts, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(ctx, GetFeeScheduleParams{
+		Currency: account.Currency,
+		Tier:     account.Tier,
+	})
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return computeFee(schedule, amount)
+}
+
+// computeFee adds the flat fee to the percentage of amount, rounded half up to the smallest unit,
+// and bounds it by the minimum and maximum of the schedule. It fails with util.ErrAmountOverflow
+// when the amount is too large to take the percentage of.
+func computeFee(schedule FeeSchedules, amount int64) (int64, error) {
+	scaled, err := util.MulAmounts(amount, schedule.PercentageBps)
+	if err != nil {
+		return 0, err
+	}
+	scaled, err = util.AddAmounts(scaled, bpsScale/2)
+	if err != nil {
+		return 0, err
+	}
+	fee, err := util.AddAmounts(schedule.FlatFee, scaled/bpsScale)
+	if err != nil {
+		return 0, err
+	}
+
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee.Valid && fee > schedule.MaxFee.Int64 {
+		fee = schedule.MaxFee.Int64
+	}
+
+	return fee, nil
+}
+
+// chargeFee takes the fee of the transfer out of its FromAccount into the revenue account of the
+// currency, both entries are linked to the transfer. It returns the FromAccount after the fee.
+// The revenue accounts are locked last, after the customer and cash accounts.
+func chargeFee(ctx context.Context, q *Queries, transfer Transfers, currency string, fee int64) (account Accounts, entry Entries, err error) {
+	revenueAccount, err := revenueAccountOf(ctx, q, currency)
+	if err != nil {
+		return
+	}
+
+	_, err = q.GetAccountForUpdate(ctx, revenueAccount.ID)
+	if err != nil {
+		return
+	}
+
+	entry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  transfer.FromAccountID,
+		Amount:     -fee,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+		Fee:        true,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  revenueAccount.ID,
+		Amount:     fee,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+		Fee:        true,
+	})
+	if err != nil {
+		return
+	}
+
+	account, _, err = addMoney(ctx, q, transfer.FromAccountID, -fee, revenueAccount.ID, fee)
+	return
+}
+
+// revenueAccountOf gets the revenue account of the currency, failing with ErrRevenueAccountNotFound
+// when there is none
+func revenueAccountOf(ctx context.Context, q *Queries, currency string) (Accounts, error) {
+	revenueAccount, err := q.GetRevenueAccount(ctx, currency)
+	if err == sql.ErrNoRows {
+		return revenueAccount, fmt.Errorf("%w: %s", ErrRevenueAccountNotFound, currency)
+	}
+
+	return revenueAccount, err
+}
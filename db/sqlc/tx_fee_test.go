@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	// a tier of its own, so the schedule doesn't charge the accounts of other tests
+	tier := util.RandomString(8)
+	_, err := testQueries.CreateFeeSchedule(context.Background(), CreateFeeScheduleParams{
+		Currency:      "USD",
+		Tier:          sql.NullString{String: tier, Valid: true},
+		FlatFee:       5,
+		PercentageBps: 100,
+	})
+	require.NoError(t, err)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+	account1 = fundAccount(t, account1, 1000)
+
+	account1, err = testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account1.ID,
+		Tier: tier,
+	})
+	require.NoError(t, err)
+
+	revenue, err := testQueries.GetRevenueAccount(context.Background(), "USD")
+	require.NoError(t, err)
+
+	fee, err := store.TransferFee(context.Background(), account1, 1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(15), fee)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, fee, result.Fee)
+	require.Equal(t, -fee, result.FeeEntry.Amount)
+	require.True(t, result.FeeEntry.Fee)
+	require.False(t, result.FromEntry.Fee)
+	require.Equal(t, result.Transfer.ID, result.FeeEntry.TransferID.Int64)
+	require.Equal(t, account1.Balance-1000-fee, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+1000, result.ToAccount.Balance)
+
+	// the revenue account collected the fee
+	entries, err := testQueries.ListTransferEntries(context.Background(), sql.NullInt64{Int64: result.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, revenue.ID, entries[3].AccountID)
+	require.Equal(t, fee, entries[3].Amount)
+
+	// the fee counts in the funds the transfer needs
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        result.FromAccount.Balance,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxNoFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "EUR")
+	require.Equal(t, AccountTierStandard, account1.Tier)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Fee)
+	require.Zero(t, result.FeeEntry.ID)
+}
+
+func TestComputeFee(t *testing.T) {
+	schedule := FeeSchedules{
+		FlatFee:       10,
+		PercentageBps: 250,
+		MinFee:        50,
+		MaxFee:        sql.NullInt64{Int64: 1000, Valid: true},
+	}
+	mustComputeFee := func(amount int64) int64 {
+		fee, err := computeFee(schedule, amount)
+		require.NoError(t, err)
+		return fee
+	}
+
+	// under the minimum
+	require.Equal(t, int64(50), mustComputeFee(100))
+	// 10 + 2.5% of 10000
+	require.Equal(t, int64(260), mustComputeFee(10000))
+	// 10 + 2.5% of 10010 rounds 250.25 down
+	require.Equal(t, int64(260), mustComputeFee(10010))
+	// 10 + 2.5% of 10020 rounds 250.5 up
+	require.Equal(t, int64(261), mustComputeFee(10020))
+	// over the maximum
+	require.Equal(t, int64(1000), mustComputeFee(1000000))
+
+	schedule.MaxFee = sql.NullInt64{}
+	require.Equal(t, int64(25010), mustComputeFee(1000000))
+}
+
+func TestComputeFeeOverflow(t *testing.T) {
+	schedule := FeeSchedules{PercentageBps: 250}
+
+	// the percentage of the largest amounts doesn't fit in int64 before it's scaled down
+	_, err := computeFee(schedule, math.MaxInt64/100)
+	require.ErrorIs(t, err, util.ErrAmountOverflow)
+
+	// the flat fee plus the percentage doesn't fit in int64
+	schedule = FeeSchedules{FlatFee: math.MaxInt64, PercentageBps: 250}
+	_, err = computeFee(schedule, 10000)
+	require.ErrorIs(t, err, util.ErrAmountOverflow)
+}
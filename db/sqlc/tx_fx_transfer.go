@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/homocode/bank_demo/fx"
+	"github.com/homocode/bank_demo/util"
+)
+
+// Different types of error returned when applying a rate quote to a transfer
+var (
+	ErrQuoteExpired  = errors.New("rate quote has expired")
+	ErrQuoteUsed     = errors.New("rate quote already used")
+	ErrQuoteMismatch = errors.New("rate quote doesn't match the transfer")
+)
+
+// fxTransferTx performs a cross-currency transfer using the rate of the quote arg.QuoteID.
+// The FromAccount is debited arg.Amount in its currency and the ToAccount is credited the converted
+// amount in its own currency. The converted amount is rounded down and the rounded off residue is
+// recorded on the transfer along with the applied rate. The quote can't be used again.
+//
+// The conversion goes through the cash accounts, the amount is credited to the cash account of the
+// source currency and the exact converted amount, rounded half up, debited from the one of the target
+// currency. When that's one more than the converted amount, the residue is booked as that unit to the
+// revenue account of the target currency, so the entries of each currency add up to zero.
+func fxTransferTx(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountId, arg.ToAccountId)
+	if err != nil {
+		return
+	}
+
+	// lock the quote so it can only be used by one transfer
+	quote, err := q.GetRateQuoteForUpdate(ctx, arg.QuoteID)
+	if err != nil {
+		return
+	}
+
+	err = checkQuote(quote, fromAccount, toAccount)
+	if err != nil {
+		return
+	}
+
+	err = checkStatus(fromAccount, toAccount)
+	if err != nil {
+		return
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	total, err := util.AddAmounts(arg.Amount, fee)
+	if err != nil {
+		return
+	}
+
+	err = checkFunds(ctx, q, fromAccount, total, "transfer")
+	if err != nil {
+		return
+	}
+
+	err = checkLimits(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	toAmount, residue, err := fx.Convert(arg.Amount, quote.Rate)
+	if err != nil {
+		return
+	}
+	if toAmount == 0 {
+		err = fmt.Errorf("%w: amount %d %s converts to zero %s", ErrQuoteMismatch, arg.Amount, quote.FromCurrency, quote.ToCurrency)
+		return
+	}
+
+	fromCash, err := cashAccountOf(ctx, q, fromAccount.Currency)
+	if err != nil {
+		return
+	}
+	toCash, err := cashAccountOf(ctx, q, toAccount.Currency)
+	if err != nil {
+		return
+	}
+	// the cash accounts are locked after the customer ones, see cashTx
+	_, _, err = lockAccounts(ctx, q, fromCash.ID, toCash.ID)
+	if err != nil {
+		return
+	}
+
+	// the residue is the bank's, in whole units of the target currency
+	var rounding int64
+	if residue*2 >= fx.RateScale {
+		rounding = 1
+	}
+	converted, err := util.AddAmounts(toAmount, rounding)
+	if err != nil {
+		return
+	}
+
+	// the revenue accounts are locked last, both in a consistent order when the fee needs the other one
+	var toRevenue Accounts
+	if rounding != 0 {
+		toRevenue, err = revenueAccountOf(ctx, q, toAccount.Currency)
+		if err != nil {
+			return
+		}
+
+		if fee != 0 {
+			var fromRevenue Accounts
+			fromRevenue, err = revenueAccountOf(ctx, q, fromAccount.Currency)
+			if err != nil {
+				return
+			}
+			_, _, err = lockAccounts(ctx, q, fromRevenue.ID, toRevenue.ID)
+		} else {
+			_, err = q.GetAccountForUpdate(ctx, toRevenue.ID)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	result.Transfer, err = q.CreateFxTransfer(ctx, CreateFxTransferParams{
+		FromAccountID: arg.FromAccountId,
+		ToAccountID:   arg.ToAccountId,
+		Amount:        arg.Amount,
+		ToAmount:      sql.NullInt64{Int64: toAmount, Valid: true},
+		ExchangeRate:  sql.NullInt64{Int64: quote.Rate, Valid: true},
+		FxResidue:     sql.NullInt64{Int64: residue, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	err = q.SetRateQuoteTransfer(ctx, SetRateQuoteTransferParams{
+		ID:         quote.ID,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	// the money leaves the FromAccount in its currency
+	result.FromEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  arg.FromAccountId,
+		Amount:     -arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	// and enters the ToAccount converted to its currency
+	result.ToEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  arg.ToAccountId,
+		Amount:     toAmount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	// the cash accounts take the opposite side of each entry
+	_, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  fromCash.ID,
+		Amount:     arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  toCash.ID,
+		Amount:     -converted,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountId, -arg.Amount, arg.ToAccountId, toAmount)
+	if err != nil {
+		return
+	}
+
+	_, _, err = addMoney(ctx, q, fromCash.ID, arg.Amount, toCash.ID, -converted)
+	if err != nil {
+		return
+	}
+
+	if rounding != 0 {
+		_, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+			AccountID:  toRevenue.ID,
+			Amount:     rounding,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return
+		}
+
+		_, err = q.AddAmountToAccountBalance(ctx, AddAmountToAccountBalanceParams{
+			ID:     toRevenue.ID,
+			Amount: rounding,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	if fee == 0 {
+		return
+	}
+
+	// the fee is charged in the currency of the FromAccount
+	result.Fee = fee
+	result.FromAccount, result.FeeEntry, err = chargeFee(ctx, q, result.Transfer, fromAccount.Currency, fee)
+	return
+}
+
+// checkQuote verifies the quote can be applied to a transfer between the accounts
+func checkQuote(quote RateQuotes, fromAccount Accounts, toAccount Accounts) error {
+	if quote.TransferID.Valid {
+		return fmt.Errorf("%w: quote %d by transfer %d", ErrQuoteUsed, quote.ID, quote.TransferID.Int64)
+	}
+
+	if time.Now().After(quote.ExpiresAt) {
+		return fmt.Errorf("%w: quote %d expired at %v", ErrQuoteExpired, quote.ID, quote.ExpiresAt)
+	}
+
+	if quote.Owner != fromAccount.Owner {
+		return fmt.Errorf("%w: quote %d belongs to another user", ErrQuoteMismatch, quote.ID)
+	}
+
+	if quote.FromCurrency != fromAccount.Currency || quote.ToCurrency != toAccount.Currency {
+		return fmt.Errorf("%w: quote %d is %s/%s, accounts are %s/%s", ErrQuoteMismatch,
+			quote.ID, quote.FromCurrency, quote.ToCurrency, fromAccount.Currency, toAccount.Currency)
+	}
+
+	return nil
+}
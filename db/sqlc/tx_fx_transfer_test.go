@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/fx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFxTransferTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user, "USD")
+	account2, _, _ := persistRandomAccount(t, user, "ARS")
+	// 350.123456 ARS per USD, converting 7 leaves a residue
+	quote, _, err := persistRandomQuote(t, user, "USD", "ARS", 350_123_456, time.Minute)
+	require.NoError(t, err)
+
+	amount := int64(7)
+	account1 = fundAccount(t, account1, amount)
+
+	usdCashBefore, err := testQueries.GetCashAccount(context.Background(), "USD")
+	require.NoError(t, err)
+	arsCashBefore, err := testQueries.GetCashAccount(context.Background(), "ARS")
+	require.NoError(t, err)
+	arsRevenueBefore, err := testQueries.GetRevenueAccount(context.Background(), "ARS")
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+		QuoteID:       quote.ID,
+	}
+
+	// Run n concurrent transfers with the same quote, only one can use it
+	n := 5
+	results := make(chan TransferTxResult, n)
+	errs := make(chan error, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			result, err := store.TransferTx(context.Background(), arg)
+			results <- result
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	var result TransferTxResult
+	var succeeded int
+	for err := range errs {
+		r := <-results
+		if err == nil {
+			succeeded++
+			result = r
+			continue
+		}
+		require.ErrorIs(t, err, ErrQuoteUsed)
+	}
+	require.Equal(t, 1, succeeded)
+
+	// 7 * 350.123456 = 2450.864192, the credited amount is rounded down
+	transfer := result.Transfer
+	require.Equal(t, amount, transfer.Amount)
+	require.Equal(t, int64(2450), transfer.ToAmount.Int64)
+	require.Equal(t, quote.Rate, transfer.ExchangeRate.Int64)
+	require.Equal(t, int64(864_192), transfer.FxResidue.Int64)
+	require.Equal(t, amount*quote.Rate, transfer.ToAmount.Int64*fx.RateScale+transfer.FxResidue.Int64)
+
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, transfer.ToAmount.Int64, result.ToEntry.Amount)
+
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+transfer.ToAmount.Int64, result.ToAccount.Balance)
+
+	// the cash accounts take the other side of the conversion
+	usdCash, err := testQueries.GetCashAccount(context.Background(), "USD")
+	require.NoError(t, err)
+	arsCash, err := testQueries.GetCashAccount(context.Background(), "ARS")
+	require.NoError(t, err)
+	require.Equal(t, usdCashBefore.Balance+amount, usdCash.Balance)
+	// 2450.864192 rounds half up to 2451, the residue is booked as the unit the ToAccount isn't credited
+	require.Equal(t, arsCashBefore.Balance-transfer.ToAmount.Int64-1, arsCash.Balance)
+
+	arsRevenue, err := testQueries.GetRevenueAccount(context.Background(), "ARS")
+	require.NoError(t, err)
+	require.Equal(t, arsRevenueBefore.Balance+1, arsRevenue.Balance)
+
+	entries, err := testQueries.ListTransferEntries(context.Background(), sql.NullInt64{Int64: transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+	require.Equal(t, arsRevenue.ID, entries[4].AccountID)
+	require.Equal(t, int64(1), entries[4].Amount)
+	require.False(t, entries[4].Fee)
+
+	// the entries of each currency add up to zero
+	var usdTotal, arsTotal int64
+	for _, entry := range entries {
+		if entry.AccountID == account1.ID || entry.AccountID == usdCash.ID {
+			usdTotal += entry.Amount
+		} else {
+			arsTotal += entry.Amount
+		}
+	}
+	require.Zero(t, usdTotal)
+	require.Zero(t, arsTotal)
+
+	usedQuote, err := testQueries.GetRateQuote(context.Background(), quote.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, usedQuote.TransferID.Int64)
+}
+
+func TestFxTransferTxInvalidQuote(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	account2, _, _ := persistRandomAccount(t, user1, "ARS")
+	account3, _, _ := persistRandomAccount(t, user1, "EUR")
+	account1 = fundAccount(t, account1, 100)
+
+	expired, _, err := persistRandomQuote(t, user1, "USD", "ARS", 350*fx.RateScale, -time.Minute)
+	require.NoError(t, err)
+	notOwned, _, err := persistRandomQuote(t, user2, "USD", "ARS", 350*fx.RateScale, time.Minute)
+	require.NoError(t, err)
+	valid, _, err := persistRandomQuote(t, user1, "USD", "ARS", 350*fx.RateScale, time.Minute)
+	require.NoError(t, err)
+	tiny, _, err := persistRandomQuote(t, user1, "USD", "ARS", fx.RateScale/1000, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		arg  TransferTxParams
+		err  error
+	}{
+		{
+			name: "Expired",
+			arg:  TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10, QuoteID: expired.ID},
+			err:  ErrQuoteExpired,
+		},
+		{
+			name: "NotOwned",
+			arg:  TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10, QuoteID: notOwned.ID},
+			err:  ErrQuoteMismatch,
+		},
+		{
+			name: "CurrencyMismatch",
+			arg:  TransferTxParams{FromAccountId: account1.ID, ToAccountId: account3.ID, Amount: 10, QuoteID: valid.ID},
+			err:  ErrQuoteMismatch,
+		},
+		{
+			name: "ConvertsToZero",
+			arg:  TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10, QuoteID: tiny.ID},
+			err:  ErrQuoteMismatch,
+		},
+		{
+			name: "InsufficientFunds",
+			arg:  TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: account1.Balance + 1, QuoteID: valid.ID},
+			err:  ErrInsufficientFunds,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.TransferTx(context.Background(), tc.arg)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// nothing moved
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
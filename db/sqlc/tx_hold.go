@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+)
+
+// Status of a hold, an authorized hold reserves funds until it's captured, voided or expires
+const (
+	HoldAuthorized = "authorized"
+	HoldCaptured   = "captured"
+	HoldVoided     = "voided"
+)
+
+// Different types of error returned by CaptureTx and VoidTx
+var (
+	ErrHoldNotAuthorized  = errors.New("hold is not authorized")
+	ErrHoldExpired        = errors.New("hold expired")
+	ErrCaptureExceedsHold = errors.New("capture exceeds the hold amount")
+)
+
+type AuthorizeTxParams struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	ExpiresAt     time.Time
+}
+
+type CaptureTxParams struct {
+	HoldID int64
+	// Amount to transfer, zero captures the whole hold
+	Amount int64
+}
+
+type CaptureTxResult struct {
+	Hold     Holds
+	Transfer TransferTxResult
+}
+
+// AuthorizeTx reserves funds of the FromAccount for a later transfer to the ToAccount.
+// The funds stop counting in the available balance of the account until the hold is captured,
+// voided or expires. The fee a transfer of the amount would be charged is reserved with it, so
+// the capture can pay it. It fails with ErrInsufficientFunds when the available balance doesn't
+// cover the amount and fee.
+func (store *SQLStore) AuthorizeTx(ctx context.Context, arg AuthorizeTxParams) (Holds, error) {
+	var hold Holds
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// lock the account, concurrent authorizations and transfers wait here
+		// so they see the funds reserved by this hold
+		account, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		err = checkCanSend(account)
+		if err != nil {
+			return err
+		}
+
+		fee, err := transferFee(ctx, q, account, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		total, err := util.AddAmounts(arg.Amount, fee)
+		if err != nil {
+			return err
+		}
+
+		err = checkFunds(ctx, q, account, total, "hold")
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.CreateHold(ctx, CreateHoldParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Fee:           fee,
+			ExpiresAt:     arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// CaptureTx settles an authorized hold with a transfer of up to its amount. A partial capture
+// releases the rest, a hold is captured only once. The transfer is charged the fee of the amount
+// captured, up to the fee reserved with the hold.
+func (store *SQLStore) CaptureTx(ctx context.Context, arg CaptureTxParams) (CaptureTxResult, error) {
+	var result CaptureTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := lockAuthorizedHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		if hold.ExpiresAt.Before(time.Now()) {
+			return fmt.Errorf("%w: hold %d expired at %v", ErrHoldExpired, hold.ID, hold.ExpiresAt)
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("%w: hold %d of %d, capture amount %d", ErrCaptureExceedsHold, hold.ID, hold.Amount, amount)
+		}
+
+		// release the hold before transferring, so the funds it reserved are available to the transfer
+		_, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:     hold.ID,
+			Status: HoldCaptured,
+		})
+		if err != nil {
+			return err
+		}
+
+		fromAccount, toAccount, err := lockAccounts(ctx, q, hold.FromAccountID, hold.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		// the schedule may have changed since the authorization, the hold only reserved its fee
+		fee, err := transferFee(ctx, q, fromAccount, amount)
+		if err != nil {
+			return err
+		}
+		if fee > hold.Fee {
+			fee = hold.Fee
+		}
+
+		result.Transfer, err = chargedTransfer(ctx, q, fromAccount, toAccount, TransferTxParams{
+			FromAccountId: hold.FromAccountID,
+			ToAccountId:   hold.ToAccountID,
+			Amount:        amount,
+		}, fee)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:         hold.ID,
+			Status:     HoldCaptured,
+			TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// VoidTx cancels an authorized hold, releasing the funds it reserved.
+// Expired holds can be voided too, to record they won't be captured.
+func (store *SQLStore) VoidTx(ctx context.Context, holdID int64) (Holds, error) {
+	var hold Holds
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		hold, err = lockAuthorizedHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:     hold.ID,
+			Status: HoldVoided,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// lockAuthorizedHold selects the hold for update, so it can only be captured or voided once,
+// and fails with ErrHoldNotAuthorized when it was already
+func lockAuthorizedHold(ctx context.Context, q *Queries, holdID int64) (Holds, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldAuthorized {
+		return hold, fmt.Errorf("%w: hold %d is %s", ErrHoldNotAuthorized, hold.ID, hold.Status)
+	}
+
+	return hold, nil
+}
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeCaptureTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+
+	account1 = fundAccount(t, account1, 100)
+	balance := account1.Balance
+
+	hold, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        balance,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldAuthorized, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+
+	// the ledger balance is untouched, the funds are only reserved
+	held, err := testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, balance, held)
+
+	// nothing is left to transfer or hold
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// capturing more than held fails
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID, Amount: balance + 1})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	// a partial capture releases the rest
+	result, err := store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID, Amount: 30})
+	require.NoError(t, err)
+	require.Equal(t, HoldCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(30), result.Transfer.Transfer.Amount)
+	require.Equal(t, balance-30, result.Transfer.FromAccount.Balance)
+
+	held, err = testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	// a hold is captured only once
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+
+	_, err = store.VoidTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestVoidTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "EUR")
+
+	hold, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	voided, err := store.VoidTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldVoided, voided.Status)
+
+	// the funds are available again
+	held, err := testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestHoldExpired(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "ARS")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "ARS")
+
+	hold, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance,
+		ExpiresAt:     time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	// an expired hold doesn't reserve funds
+	held, err := testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	_, err = store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	_, err = store.VoidTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+}
+
+func TestAuthorizeTxConcurrent(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+
+	// n concurrent holds, the account can only afford k of them
+	amount := int64(100)
+	n := 10
+	k := 4
+	fundAccount(t, account1, amount*int64(k)-account1.Balance)
+
+	errs := make(chan error, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				ExpiresAt:     time.Now().Add(time.Minute),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	require.Equal(t, k, succeeded)
+
+	held, err := testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, amount*int64(k), held)
+}
+
+func TestCaptureTxFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	// tiers of their own, so the schedules don't charge the accounts of other tests
+	tier := util.RandomString(8)
+	_, err := testQueries.CreateFeeSchedule(context.Background(), CreateFeeScheduleParams{
+		Currency:      "USD",
+		Tier:          sql.NullString{String: tier, Valid: true},
+		FlatFee:       5,
+		PercentageBps: 100,
+	})
+	require.NoError(t, err)
+	expensiveTier := util.RandomString(8)
+	_, err = testQueries.CreateFeeSchedule(context.Background(), CreateFeeScheduleParams{
+		Currency: "USD",
+		Tier:     sql.NullString{String: expensiveTier, Valid: true},
+		FlatFee:  50,
+	})
+	require.NoError(t, err)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "USD")
+	require.NoError(t, err)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "USD")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 2000)
+	account1, err = testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account1.ID,
+		Tier: tier,
+	})
+	require.NoError(t, err)
+
+	// the fee is reserved with the amount
+	hold, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(15), hold.Fee)
+
+	held, err := testQueries.SumActiveHolds(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1015), held)
+
+	// the available balance covers the amount but not its fee
+	_, err = store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance - held,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// a partial capture is charged the fee of the amount captured
+	result, err := store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID, Amount: 500})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Transfer.Fee)
+	require.Equal(t, account1.Balance-500-10, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account2.Balance+500, result.Transfer.ToAccount.Balance)
+
+	// a capture isn't charged more than the hold reserved, even if the schedule changed since
+	hold, err = store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), hold.Fee)
+
+	_, err = testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account1.ID,
+		Tier: expensiveTier,
+	})
+	require.NoError(t, err)
+
+	result, err = store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, hold.Fee, result.Transfer.Fee)
+	require.Equal(t, account1.Balance-500-10-100-6, result.Transfer.FromAccount.Balance)
+}
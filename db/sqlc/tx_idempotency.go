@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+// with a request that doesn't match the one it was first used with
+var ErrIdempotencyKeyReused = errors.New("idempotency key already used with a different request")
+
+// IdempotencyParams identifies an idempotent request. RequestHash is the fingerprint
+// of the payload, a replay must match it. Keys older than Retention are discarded.
+type IdempotencyParams struct {
+	Owner       string
+	Key         string
+	RequestPath string
+	RequestHash string
+	Retention   time.Duration
+}
+
+// execIdempotentTx runs fn in a transaction only once per idempotency key, storing result as the response.
+// If the key was already used, fn is skipped and the stored response is decoded into result instead.
+//
+// The key is claimed inside the same transaction as fn, so concurrent duplicates block on the
+// primary key until the first one commits (then they replay it) or rolls back (then they run fn).
+// A failing fn rolls back the key too, so only successful responses are replayed.
+func (store *SQLStore) execIdempotentTx(ctx context.Context, idem IdempotencyParams, result interface{}, fn func(*Queries) error) (replayed bool, err error) {
+	err = store.execTx(ctx, func(q *Queries) error {
+		// a retried transaction starts over
+		replayed = false
+
+		key, claimed, err := claimIdempotencyKey(ctx, q, idem)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			if key.RequestPath != idem.RequestPath || key.RequestHash != idem.RequestHash {
+				return ErrIdempotencyKeyReused
+			}
+
+			replayed = true
+			return json.Unmarshal(key.ResponseBody, result)
+		}
+
+		err = fn(q)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		return q.SetIdempotencyKeyResponse(ctx, SetIdempotencyKeyResponseParams{
+			Owner:        idem.Owner,
+			Key:          idem.Key,
+			ResponseBody: body,
+		})
+	})
+
+	return replayed, err
+}
+
+// claimIdempotencyKey persists the key, when it already exists it returns the stored one locked
+// for update and claimed set to false. Expired keys are replaced by the new request.
+func claimIdempotencyKey(ctx context.Context, q *Queries, idem IdempotencyParams) (key IdempotencyKeys, claimed bool, err error) {
+	arg := CreateIdempotencyKeyParams{
+		Owner:       idem.Owner,
+		Key:         idem.Key,
+		RequestPath: idem.RequestPath,
+		RequestHash: idem.RequestHash,
+	}
+
+	key, err = q.CreateIdempotencyKey(ctx, arg)
+	if err == nil {
+		return key, true, nil
+	}
+	// ErrNoRows means there was a conflict, the key is already stored
+	if err != sql.ErrNoRows {
+		return
+	}
+
+	key, err = q.GetIdempotencyKeyForUpdate(ctx, GetIdempotencyKeyForUpdateParams{
+		Owner: idem.Owner,
+		Key:   idem.Key,
+	})
+	if err != nil {
+		return
+	}
+
+	if time.Since(key.CreatedAt) <= idem.Retention {
+		return key, false, nil
+	}
+
+	err = q.DeleteIdempotencyKey(ctx, DeleteIdempotencyKeyParams{
+		Owner: idem.Owner,
+		Key:   idem.Key,
+	})
+	if err != nil {
+		return
+	}
+
+	key, err = q.CreateIdempotencyKey(ctx, arg)
+	if err != nil {
+		return
+	}
+
+	return key, true, nil
+}
+
+// TransferTxIdempotent performs TransferTx once per idempotency key. Retries with the same key
+// and payload get the original TransferTxResult with replayed set to true.
+func (store *SQLStore) TransferTxIdempotent(ctx context.Context, idem IdempotencyParams, arg TransferTxParams) (result TransferTxResult, replayed bool, err error) {
+	replayed, err = store.execIdempotentTx(ctx, idem, &result, func(q *Queries) error {
+		var err error
+		result, err = transferTx(ctx, q, arg)
+		return err
+	})
+
+	return
+}
+
+// CreateAccountIdempotent creates the account once per idempotency key. Retries with the same key
+// and payload get the originally created account with replayed set to true.
+func (store *SQLStore) CreateAccountIdempotent(ctx context.Context, idem IdempotencyParams, arg CreateAccountParams) (account Accounts, replayed bool, err error) {
+	replayed, err = store.execIdempotentTx(ctx, idem, &account, func(q *Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, arg)
+		return err
+	})
+
+	return
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxIdempotent(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "")
+	amount := int64(400)
+
+	// fund the account to afford every transfer, in case the key doesn't work
+	n := 5
+	account1 = fundAccount(t, account1, amount*int64(n))
+
+	idem := IdempotencyParams{
+		Owner:       user1.Email,
+		Key:         util.RandomString(16),
+		RequestPath: "/transfer",
+		RequestHash: util.RandomString(64),
+		Retention:   time.Hour,
+	}
+
+	arg := TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+	}
+
+	// Run n concurrent duplicate requests, only one of them must move the money
+	results := make(chan TransferTxResult, n)
+	replays := make(chan bool, n)
+	errs := make(chan error, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			result, replayed, err := store.TransferTxIdempotent(context.Background(), idem, arg)
+			results <- result
+			replays <- replayed
+			errs <- err
+		}()
+	}
+	wg.Wait()
+
+	var first TransferTxResult
+	var replayed int
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+
+		result := <-results
+		require.NotZero(t, result.Transfer.ID)
+		if first.Transfer.ID == 0 {
+			first = result
+		}
+		// every response is the original one
+		require.Equal(t, first.Transfer.ID, result.Transfer.ID)
+		require.Equal(t, first.FromEntry.ID, result.FromEntry.ID)
+		require.Equal(t, first.ToEntry.ID, result.ToEntry.ID)
+		require.Equal(t, first.FromAccount.Balance, result.FromAccount.Balance)
+		require.Equal(t, first.ToAccount.Balance, result.ToAccount.Balance)
+
+		if <-replays {
+			replayed++
+		}
+	}
+	require.Equal(t, n-1, replayed)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+
+	// the same key with another payload is rejected
+	idem.RequestHash = util.RandomString(64)
+	_, _, err = store.TransferTxIdempotent(context.Background(), idem, arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestTransferTxIdempotentFailureIsNotStored(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "")
+
+	idem := IdempotencyParams{
+		Owner:       user1.Email,
+		Key:         util.RandomString(16),
+		RequestPath: "/transfer",
+		RequestHash: util.RandomString(64),
+		Retention:   time.Hour,
+	}
+
+	arg := TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        account1.Balance + 1,
+	}
+
+	_, _, err := store.TransferTxIdempotent(context.Background(), idem, arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// once the account is funded the retry with the same key goes through
+	fundAccount(t, account1, 1)
+	result, replayed, err := store.TransferTxIdempotent(context.Background(), idem, arg)
+	require.NoError(t, err)
+	require.False(t, replayed)
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+}
+
+func TestCreateAccountIdempotent(t *testing.T) {
+	store := NewStore(testDb)
+
+	user, _, _ := persistRandomUser(t, "")
+	idem := IdempotencyParams{
+		Owner:       user.Email,
+		Key:         util.RandomString(16),
+		RequestPath: "/accounts",
+		RequestHash: util.RandomString(64),
+		Retention:   time.Hour,
+	}
+
+	arg := CreateAccountParams{
+		Owner:    user.Email,
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+	}
+
+	account, replayed, err := store.CreateAccountIdempotent(context.Background(), idem, arg)
+	require.NoError(t, err)
+	require.False(t, replayed)
+
+	// without the key the retry would violate the owner/currency unique constraint
+	replayedAccount, replayed, err := store.CreateAccountIdempotent(context.Background(), idem, arg)
+	require.NoError(t, err)
+	require.True(t, replayed)
+	require.Equal(t, account.ID, replayedAccount.ID)
+	require.Equal(t, account.Owner, replayedAccount.Owner)
+	require.Equal(t, account.Currency, replayedAccount.Currency)
+	require.WithinDuration(t, account.CreatedAt.Time, replayedAccount.CreatedAt.Time, time.Millisecond)
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Kinds of discrepancies found by ReconcileTx
+const (
+	// the balance of an account isn't the sum of its entries
+	DiscrepancyAccountBalance = "account_balance"
+	// the entries of a currency don't add up to zero, money was created or destroyed
+	DiscrepancyCurrencyBalance = "currency_balance"
+	// a transfer doesn't have its two entries, the debit of the amount to the from account
+	// followed by the credit to the to account
+	DiscrepancyTransferEntries = "transfer_entries"
+	// the entries of a transfer don't add up to zero in a currency
+	DiscrepancyTransferBalance = "transfer_balance"
+)
+
+type ReconciliationResult struct {
+	Report        ReconciliationReports
+	Discrepancies []ReconciliationDiscrepancies
+}
+
+// ReconcileTx checks the invariants of the ledger and writes a report with the discrepancies found.
+// The checks run in a repeatable read transaction, so they all see the same snapshot of the ledger
+// while transfers keep being booked.
+func (store *SQLStore) ReconcileTx(ctx context.Context) (ReconciliationResult, error) {
+	var result ReconciliationResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		targets, err := q.CountReconciliationTargets(ctx)
+		if err != nil {
+			return err
+		}
+
+		discrepancies, err := findDiscrepancies(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		result.Report, err = q.CreateReconciliationReport(ctx, CreateReconciliationReportParams{
+			AccountsChecked:  targets.Accounts,
+			TransfersChecked: targets.Transfers,
+			Discrepancies:    int64(len(discrepancies)),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Discrepancies = make([]ReconciliationDiscrepancies, len(discrepancies))
+		for i, discrepancy := range discrepancies {
+			discrepancy.ReportID = result.Report.ID
+			result.Discrepancies[i], err = q.CreateReconciliationDiscrepancy(ctx, discrepancy)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, WithIsolation(sql.LevelRepeatableRead))
+
+	return result, err
+}
+
+// findDiscrepancies runs every check, the ReportID of the discrepancies is left to set
+func findDiscrepancies(ctx context.Context, q *Queries) ([]CreateReconciliationDiscrepancyParams, error) {
+	var discrepancies []CreateReconciliationDiscrepancyParams
+
+	accounts, err := q.ListAccountBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+			Kind:      DiscrepancyAccountBalance,
+			AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			Currency:  sql.NullString{String: account.Currency, Valid: true},
+			Expected:  account.EntriesTotal,
+			Actual:    account.Balance,
+		})
+	}
+
+	currencies, err := q.ListCurrencyBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, currency := range currencies {
+		discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+			Kind:     DiscrepancyCurrencyBalance,
+			Currency: sql.NullString{String: currency.Currency, Valid: true},
+			Expected: 0,
+			Actual:   currency.EntriesTotal,
+		})
+	}
+
+	transfers, err := q.ListTransferEntryMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, transfer := range transfers {
+		discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+			Kind:       DiscrepancyTransferEntries,
+			TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+			Expected:   2,
+			Actual:     int64(transfer.Matching),
+		})
+	}
+
+	unbalanced, err := q.ListTransferBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, transfer := range unbalanced {
+		discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+			Kind:       DiscrepancyTransferBalance,
+			TransferID: sql.NullInt64{Int64: transfer.TransferID, Valid: true},
+			Currency:   sql.NullString{String: transfer.Currency, Valid: true},
+			Expected:   0,
+			Actual:     transfer.EntriesTotal,
+		})
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// findDiscrepancy returns the discrepancy of the kind about the account or the transfer, false when there's none
+func findDiscrepancy(discrepancies []ReconciliationDiscrepancies, kind string, accountID int64, transferID int64) (ReconciliationDiscrepancies, bool) {
+	for _, d := range discrepancies {
+		if d.Kind == kind && d.AccountID.Int64 == accountID && d.TransferID.Int64 == transferID {
+			return d, true
+		}
+	}
+
+	return ReconciliationDiscrepancies{}, false
+}
+
+func TestReconcileTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+
+	// the test accounts are created with a balance and no entries
+	booked, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+
+	// a transfer that lost its credit
+	broken, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateTransferEntry(context.Background(), CreateTransferEntryParams{
+		AccountID:  account1.ID,
+		Amount:     -1,
+		TransferID: sql.NullInt64{Int64: broken.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReconcileTx(context.Background())
+	require.NoError(t, err)
+	require.NotZero(t, result.Report.ID)
+	require.Equal(t, int64(len(result.Discrepancies)), result.Report.Discrepancies)
+	require.GreaterOrEqual(t, result.Report.TransfersChecked, int64(2))
+
+	// account1 has an entry of -1 for the transfer and another for the broken one
+	d, found := findDiscrepancy(result.Discrepancies, DiscrepancyAccountBalance, account1.ID, 0)
+	require.True(t, found)
+	require.Equal(t, int64(-2), d.Expected)
+	require.Equal(t, account1.Balance-1, d.Actual)
+	require.Equal(t, "USD", d.Currency.String)
+
+	d, found = findDiscrepancy(result.Discrepancies, DiscrepancyTransferEntries, 0, broken.ID)
+	require.True(t, found)
+	require.Equal(t, int64(2), d.Expected)
+	require.Equal(t, int64(1), d.Actual)
+
+	d, found = findDiscrepancy(result.Discrepancies, DiscrepancyTransferBalance, 0, broken.ID)
+	require.True(t, found)
+	require.Equal(t, int64(0), d.Expected)
+	require.Equal(t, int64(-1), d.Actual)
+
+	// the transfer booked by TransferTx reconciles
+	for _, d := range result.Discrepancies {
+		require.NotEqual(t, booked.Transfer.ID, d.TransferID.Int64)
+	}
+
+	latest, err := testQueries.GetLatestReconciliationReport(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, latest.ID, result.Report.ID)
+
+	discrepancies, err := testQueries.ListReconciliationDiscrepancies(context.Background(), result.Report.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Discrepancies, discrepancies)
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Different types of error returned by ReverseTransferTx
+var (
+	ErrTransferNotReversible = errors.New("transfer can't be reversed")
+	ErrReversalExceedsAmount = errors.New("reversal exceeds the transfer amount")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64
+	// Amount to refund, zero refunds all that wasn't reversed yet
+	Amount int64
+}
+
+// ReverseTransferTx refunds a transfer, fully or partially, with a new transfer in the opposite direction
+// linked to the original one. Its entries point to the entries of the original transfer they compensate.
+// Reversals of the same transfer never add up to more than its amount. The fee of the original transfer
+// is refunded in proportion to the amount reversed, see refundFee.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = reverseTransferTx(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func reverseTransferTx(ctx context.Context, q *Queries, arg ReverseTransferTxParams) (result TransferTxResult, err error) {
+	// Lock the original transfer, concurrent reversals of it wait here until this one ends,
+	// so they see its amount when adding up what was already reversed
+	original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+	if err != nil {
+		return
+	}
+
+	if original.ReversedTransferID.Valid {
+		err = fmt.Errorf("%w: transfer %d is a reversal", ErrTransferNotReversible, original.ID)
+		return
+	}
+	if original.ExchangeRate.Valid {
+		err = fmt.Errorf("%w: transfer %d is cross-currency", ErrTransferNotReversible, original.ID)
+		return
+	}
+
+	reversed, err := q.SumTransferReversals(ctx, original.ID)
+	if err != nil {
+		return
+	}
+
+	remaining := original.Amount - reversed
+	amount := arg.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		err = fmt.Errorf("%w: transfer %d of %d has %d left to reverse, reversal amount %d",
+			ErrReversalExceedsAmount, original.ID, original.Amount, remaining, amount)
+		return
+	}
+
+	// the money goes back, from the account that received it to the one that sent it
+	fromAccountID := original.ToAccountID
+	toAccountID := original.FromAccountID
+
+	fromAccount, toAccount, err := lockAccounts(ctx, q, fromAccountID, toAccountID)
+	if err != nil {
+		return
+	}
+
+	err = checkStatus(fromAccount, toAccount)
+	if err != nil {
+		return
+	}
+
+	err = checkFunds(ctx, q, fromAccount, amount, "reversal")
+	if err != nil {
+		return
+	}
+
+	result.Transfer, err = q.CreateReversalTransfer(ctx, CreateReversalTransferParams{
+		FromAccountID:      fromAccountID,
+		ToAccountID:        toAccountID,
+		Amount:             amount,
+		ReversedTransferID: sql.NullInt64{Int64: original.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	originalEntries, err := q.ListTransferEntries(ctx, sql.NullInt64{Int64: original.ID, Valid: true})
+	if err != nil {
+		return
+	}
+
+	// the debit of the reversal compensates the credit of the original transfer and the other way around.
+	// Transfers booked before entries were linked to them have no entries to point to.
+	result.FromEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:       fromAccountID,
+		Amount:          -amount,
+		TransferID:      sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		ReversedEntryID: findEntry(originalEntries, fromAccountID, true),
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:       toAccountID,
+		Amount:          amount,
+		TransferID:      sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		ReversedEntryID: findEntry(originalEntries, toAccountID, false),
+	})
+	if err != nil {
+		return
+	}
+
+	if fromAccountID < toAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, fromAccountID, -amount, toAccountID, amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, toAccountID, amount, fromAccountID, -amount)
+	}
+	if err != nil {
+		return
+	}
+
+	feeRefund, feeRefundEntry, account, err := refundFee(ctx, q, original, originalEntries, result.Transfer, reversed)
+	if err != nil || feeRefund == 0 {
+		return
+	}
+
+	result.FeeRefund = feeRefund
+	result.FeeRefundEntry = feeRefundEntry
+	result.ToAccount = account
+	return
+}
+
+// refundFee returns to the sender of the original transfer the part of its fee of the amount of the
+// reversal, out of the revenue account that collected it. Each reversal refunds the fee of all that's
+// reversed so far, rounded down, minus what the previous ones refunded, so the whole fee is back once
+// the whole amount is. It returns the sender account after the refund.
+func refundFee(
+	ctx context.Context,
+	q *Queries,
+	original Transfers,
+	originalEntries []Entries,
+	reversal Transfers,
+	reversed int64,
+) (refund int64, entry Entries, account Accounts, err error) {
+	var charged, collected Entries
+	for _, originalEntry := range originalEntries {
+		if !originalEntry.Fee {
+			continue
+		}
+		if originalEntry.AccountID == original.FromAccountID {
+			charged = originalEntry
+		} else {
+			collected = originalEntry
+		}
+	}
+	if charged.ID == 0 || collected.ID == 0 {
+		return
+	}
+
+	fee := -charged.Amount
+	refund = proRata(fee, reversed+reversal.Amount, original.Amount) - proRata(fee, reversed, original.Amount)
+	if refund == 0 {
+		return
+	}
+
+	// the revenue account is locked last, after the customer ones, see chargeFee
+	_, err = q.GetAccountForUpdate(ctx, collected.AccountID)
+	if err != nil {
+		return
+	}
+
+	entry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:       original.FromAccountID,
+		Amount:          refund,
+		TransferID:      sql.NullInt64{Int64: reversal.ID, Valid: true},
+		ReversedEntryID: sql.NullInt64{Int64: charged.ID, Valid: true},
+		Fee:             true,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:       collected.AccountID,
+		Amount:          -refund,
+		TransferID:      sql.NullInt64{Int64: reversal.ID, Valid: true},
+		ReversedEntryID: sql.NullInt64{Int64: collected.ID, Valid: true},
+		Fee:             true,
+	})
+	if err != nil {
+		return
+	}
+
+	account, _, err = addMoney(ctx, q, original.FromAccountID, refund, collected.AccountID, -refund)
+	return
+}
+
+// proRata is the part of value that part is of whole, rounded down. part can't be over whole.
+func proRata(value int64, part int64, whole int64) int64 {
+	result := new(big.Int).Mul(big.NewInt(value), big.NewInt(part))
+	return result.Quo(result, big.NewInt(whole)).Int64()
+}
+
+// findEntry returns the id of the credit, or the debit, of the account among the entries of the amount
+func findEntry(entries []Entries, accountID int64, credit bool) sql.NullInt64 {
+	for _, entry := range entries {
+		if !entry.Fee && entry.AccountID == accountID && (entry.Amount > 0) == credit {
+			return sql.NullInt64{Int64: entry.ID, Valid: true}
+		}
+	}
+
+	return sql.NullInt64{}
+}
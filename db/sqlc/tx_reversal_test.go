@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+
+	amount := int64(100)
+	account1 = fundAccount(t, account1, amount)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.ID, original.FromEntry.TransferID.Int64)
+
+	// partial refund
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     30,
+	})
+	require.NoError(t, err)
+
+	reversal := result.Transfer
+	require.Equal(t, account2.ID, reversal.FromAccountID)
+	require.Equal(t, account1.ID, reversal.ToAccountID)
+	require.Equal(t, int64(30), reversal.Amount)
+	require.Equal(t, original.Transfer.ID, reversal.ReversedTransferID.Int64)
+
+	// the entries compensate the ones of the original transfer
+	require.Equal(t, int64(-30), result.FromEntry.Amount)
+	require.Equal(t, original.ToEntry.ID, result.FromEntry.ReversedEntryID.Int64)
+	require.Equal(t, int64(30), result.ToEntry.Amount)
+	require.Equal(t, original.FromEntry.ID, result.ToEntry.ReversedEntryID.Int64)
+
+	require.Equal(t, original.ToAccount.Balance-30, result.FromAccount.Balance)
+	require.Equal(t, original.FromAccount.Balance+30, result.ToAccount.Balance)
+
+	// a reversal can't be reversed
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: reversal.ID})
+	require.ErrorIs(t, err, ErrTransferNotReversible)
+
+	// refunding more than what's left fails
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     amount,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsAmount)
+
+	// without amount the rest is refunded
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, amount-30, result.Transfer.Amount)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.ErrorIs(t, err, ErrReversalExceedsAmount)
+}
+
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "EUR")
+
+	amount := int64(100)
+	account1 = fundAccount(t, account1, amount)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	// n concurrent partial refunds, only the ones that fit in the amount succeed
+	n := 10
+	partial := int64(30)
+	errs := make(chan error, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: original.Transfer.ID,
+				Amount:     partial,
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded int64
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrReversalExceedsAmount)
+	}
+	require.Equal(t, amount/partial, succeeded)
+
+	reversed, err := testQueries.SumTransferReversals(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, succeeded*partial, reversed)
+
+	entries, err := testQueries.ListTransferEntries(context.Background(), sql.NullInt64{Int64: original.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestReverseTransferTxFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	// a tier of its own, so the schedule doesn't charge the accounts of other tests
+	tier := util.RandomString(8)
+	_, err := testQueries.CreateFeeSchedule(context.Background(), CreateFeeScheduleParams{
+		Currency:      "USD",
+		Tier:          sql.NullString{String: tier, Valid: true},
+		FlatFee:       5,
+		PercentageBps: 100,
+	})
+	require.NoError(t, err)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "USD")
+	require.NoError(t, err)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "USD")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 1000)
+	_, err = testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account1.ID,
+		Tier: tier,
+	})
+	require.NoError(t, err)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(15), original.Fee)
+
+	revenueBefore, err := testQueries.GetRevenueAccount(context.Background(), "USD")
+	require.NoError(t, err)
+
+	// 15 * 300 / 1000 = 4.5, the refund is rounded down
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     300,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), result.FeeRefund)
+	require.Equal(t, int64(4), result.FeeRefundEntry.Amount)
+	require.True(t, result.FeeRefundEntry.Fee)
+	require.Equal(t, original.FeeEntry.ID, result.FeeRefundEntry.ReversedEntryID.Int64)
+	require.Equal(t, original.FromEntry.ID, result.ToEntry.ReversedEntryID.Int64)
+	require.Equal(t, original.FromAccount.Balance+300+4, result.ToAccount.Balance)
+
+	entries, err := testQueries.ListTransferEntries(context.Background(), sql.NullInt64{Int64: result.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, revenueBefore.ID, entries[3].AccountID)
+	require.Equal(t, int64(-4), entries[3].Amount)
+
+	// reversing the rest refunds the rest of the fee
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), result.FeeRefund)
+	require.Equal(t, original.FromAccount.Balance+1000+15, result.ToAccount.Balance)
+
+	revenue, err := testQueries.GetRevenueAccount(context.Background(), "USD")
+	require.NoError(t, err)
+	require.Equal(t, revenueBefore.Balance-original.Fee, revenue.Balance)
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status of a scheduled transfer, the worker only runs the active ones
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferCompleted = "completed"
+	ScheduledTransferFailed    = "failed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// Units of the interval between the runs of a recurring transfer
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// RetryPolicy is how a failed run of a scheduled transfer is retried. The n-th retry waits
+// Backoff * 2^(n-1). After MaxAttempts failed attempts the occurrence is skipped.
+type RetryPolicy struct {
+	MaxAttempts int32
+	Backoff     time.Duration
+}
+
+type ScheduledTransferRunResult struct {
+	ScheduledTransfer ScheduledTransfers
+	Run               ScheduledTransferRuns
+}
+
+// RunDueScheduledTransferTx claims one scheduled transfer that is due and runs it with TransferTx,
+// recording the outcome of the attempt. Rows claimed by other workers are skipped, so many workers
+// can run concurrently. It returns sql.ErrNoRows when nothing is due.
+//
+// A failed run, insufficient funds for instance, doesn't fail the transaction. It's recorded
+// and retried following policy.
+func (store *SQLStore) RunDueScheduledTransferTx(ctx context.Context, policy RetryPolicy) (ScheduledTransferRunResult, error) {
+	var result ScheduledTransferRunResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.ClaimDueScheduledTransfer(ctx)
+		if err != nil {
+			return err
+		}
+
+		transfer, runErr := runScheduledTransfer(ctx, q, scheduled)
+		// a deadlock isn't a failure of the run, the whole transaction is retried
+		if isRetryable(runErr) {
+			return runErr
+		}
+
+		attempt := scheduled.Attempts + 1
+		run := CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduled.ID,
+			Attempt:             attempt,
+		}
+		if runErr != nil {
+			run.Error = sql.NullString{String: runErr.Error(), Valid: true}
+		} else {
+			run.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, run)
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, err = q.RescheduleTransfer(ctx, nextSchedule(scheduled, runErr, policy, time.Now()))
+		return err
+	})
+
+	return result, err
+}
+
+// runScheduledTransfer books the transfer inside a savepoint, when it fails its queries are
+// rolled back but the transaction stays usable to record the failure
+func runScheduledTransfer(ctx context.Context, q *Queries, scheduled ScheduledTransfers) (TransferTxResult, error) {
+	_, err := q.db.ExecContext(ctx, "SAVEPOINT scheduled_transfer")
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := transferTx(ctx, q, TransferTxParams{
+		FromAccountId: scheduled.FromAccountID,
+		ToAccountId:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+	})
+	if err != nil {
+		if _, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT scheduled_transfer"); rbErr != nil {
+			return result, fmt.Errorf("transfer error: %v, rollback error: %v", err, rbErr)
+		}
+		return result, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT scheduled_transfer")
+	return result, err
+}
+
+// nextSchedule moves a scheduled transfer after a run that failed with runErr, nil when it succeeded.
+// A failed run is retried after a backoff until the attempts run out, then the occurrence is given up
+// like a successful run moves on: to the next occurrence of a recurring transfer, or to the end of a
+// transfer that runs once. A closed account never takes part in a transfer again, so it ends right away.
+func nextSchedule(scheduled ScheduledTransfers, runErr error, policy RetryPolicy, now time.Time) RescheduleTransferParams {
+	succeeded := runErr == nil
+	arg := RescheduleTransferParams{
+		ID:            scheduled.ID,
+		Status:        scheduled.Status,
+		NextRunAt:     scheduled.NextRunAt,
+		NextAttemptAt: scheduled.NextAttemptAt,
+		Attempts:      scheduled.Attempts + 1,
+	}
+
+	if errors.Is(runErr, ErrAccountClosed) {
+		arg.Status = ScheduledTransferFailed
+		return arg
+	}
+
+	if !succeeded && arg.Attempts < policy.MaxAttempts {
+		arg.NextAttemptAt = now.Add(policy.Backoff << (arg.Attempts - 1))
+		return arg
+	}
+
+	if !scheduled.IntervalUnit.Valid {
+		arg.Status = ScheduledTransferCompleted
+		if !succeeded {
+			arg.Status = ScheduledTransferFailed
+		}
+		return arg
+	}
+
+	// occurrences missed while the worker was down are skipped rather than run in a burst
+	after := scheduled.NextRunAt
+	if now.After(after) {
+		after = now
+	}
+
+	arg.NextRunAt = nextOccurrence(scheduled.StartsAt, scheduled.IntervalUnit.String, scheduled.IntervalCount, after)
+	arg.NextAttemptAt = arg.NextRunAt
+	arg.Attempts = 0
+	return arg
+}
+
+// nextOccurrence returns the first occurrence later than after of a transfer that starts at start
+// and repeats every count units. Occurrences are counted from start so monthly ones keep its day,
+// in shorter months they run on the last day.
+func nextOccurrence(start time.Time, unit string, count int32, after time.Time) time.Time {
+	for n := 1; ; n++ {
+		var next time.Time
+		switch unit {
+		case IntervalDay:
+			next = start.AddDate(0, 0, n*int(count))
+		case IntervalWeek:
+			next = start.AddDate(0, 0, 7*n*int(count))
+		default:
+			next = addMonths(start, n*int(count))
+		}
+
+		if next.After(after) {
+			return next
+		}
+	}
+}
+
+// addMonths adds months to t keeping its day, or moving it to the last day of shorter months
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+time.Month(months), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func persistScheduledTransfer(t *testing.T, from Accounts, to Accounts, amount int64, unit string, startsAt time.Time) ScheduledTransfers {
+	t.Helper()
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		IntervalUnit:  sql.NullString{String: unit, Valid: unit != ""},
+		IntervalCount: 1,
+		StartsAt:      startsAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferActive, scheduled.Status)
+	require.Equal(t, scheduled.StartsAt, scheduled.NextRunAt)
+
+	return scheduled
+}
+
+// runScheduled runs due transfers until the one with id, other tests may have left due ones behind
+func runScheduled(t *testing.T, store *SQLStore, id int64, policy RetryPolicy) ScheduledTransferRunResult {
+	t.Helper()
+
+	for {
+		result, err := store.RunDueScheduledTransferTx(context.Background(), policy)
+		require.NoError(t, err)
+
+		if result.ScheduledTransfer.ID == id {
+			return result
+		}
+	}
+}
+
+func TestRunDueScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDb)
+	policy := RetryPolicy{MaxAttempts: 2, Backoff: time.Hour}
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+
+	startsAt := time.Now().Add(-time.Minute)
+	scheduled := persistScheduledTransfer(t, account1, account2, account1.Balance, IntervalMonth, startsAt)
+
+	result := runScheduled(t, store, scheduled.ID, policy)
+	require.False(t, result.Run.Error.Valid)
+	require.True(t, result.Run.TransferID.Valid)
+	require.Equal(t, int32(1), result.Run.Attempt)
+
+	transfer, err := testQueries.GetTransfer(context.Background(), result.Run.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, transfer.Amount)
+
+	// the next occurrence is a month later
+	require.Equal(t, ScheduledTransferActive, result.ScheduledTransfer.Status)
+	require.WithinDuration(t, addMonths(startsAt, 1), result.ScheduledTransfer.NextRunAt, time.Second)
+	require.Equal(t, result.ScheduledTransfer.NextRunAt, result.ScheduledTransfer.NextAttemptAt)
+	require.Zero(t, result.ScheduledTransfer.Attempts)
+}
+
+func TestRunDueScheduledTransferTxRetry(t *testing.T) {
+	store := NewStore(testDb)
+	policy := RetryPolicy{MaxAttempts: 2, Backoff: time.Hour}
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "EUR")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "EUR")
+
+	// the account can't afford it, the failure is recorded and retried after the backoff
+	scheduled := persistScheduledTransfer(t, account1, account2, account1.Balance+1, "", time.Now().Add(-time.Minute))
+
+	result := runScheduled(t, store, scheduled.ID, policy)
+	require.True(t, result.Run.Error.Valid)
+	require.False(t, result.Run.TransferID.Valid)
+	require.Equal(t, ScheduledTransferActive, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Attempts)
+	require.WithinDuration(t, time.Now().Add(policy.Backoff), result.ScheduledTransfer.NextAttemptAt, time.Second)
+
+	// the balance didn't change
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+
+	// make the retry due, it fails again and the transfer is given up
+	_, err = testQueries.RescheduleTransfer(context.Background(), RescheduleTransferParams{
+		ID:            scheduled.ID,
+		Status:        ScheduledTransferActive,
+		NextRunAt:     scheduled.NextRunAt,
+		NextAttemptAt: time.Now().Add(-time.Second),
+		Attempts:      1,
+	})
+	require.NoError(t, err)
+
+	result = runScheduled(t, store, scheduled.ID, policy)
+	require.True(t, result.Run.Error.Valid)
+	require.Equal(t, int32(2), result.Run.Attempt)
+	require.Equal(t, ScheduledTransferFailed, result.ScheduledTransfer.Status)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               5,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+}
+
+func TestNextSchedule(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}
+
+	scheduled := ScheduledTransfers{
+		ID:            1,
+		IntervalUnit:  sql.NullString{String: IntervalWeek, Valid: true},
+		IntervalCount: 1,
+		Status:        ScheduledTransferActive,
+		StartsAt:      now.AddDate(0, 0, -14),
+		NextRunAt:     now.Add(-time.Hour),
+		NextAttemptAt: now.Add(-time.Hour),
+		Attempts:      1,
+	}
+
+	// the second failed attempt waits twice the backoff
+	arg := nextSchedule(scheduled, ErrInsufficientFunds, policy, now)
+	require.Equal(t, int32(2), arg.Attempts)
+	require.Equal(t, now.Add(2*time.Minute), arg.NextAttemptAt)
+	require.Equal(t, scheduled.NextRunAt, arg.NextRunAt)
+
+	// out of attempts the occurrence is skipped
+	scheduled.Attempts = 2
+	arg = nextSchedule(scheduled, ErrInsufficientFunds, policy, now)
+	require.Equal(t, ScheduledTransferActive, arg.Status)
+	require.Zero(t, arg.Attempts)
+	require.Equal(t, scheduled.StartsAt.AddDate(0, 0, 21), arg.NextRunAt)
+
+	// missed occurrences aren't run
+	scheduled.Attempts = 0
+	arg = nextSchedule(scheduled, nil, policy, now.AddDate(0, 0, 20))
+	require.Equal(t, scheduled.StartsAt.AddDate(0, 0, 35), arg.NextRunAt)
+
+	// a closed account ends a recurring transfer
+	arg = nextSchedule(scheduled, fmt.Errorf("%w: account 1", ErrAccountClosed), policy, now)
+	require.Equal(t, ScheduledTransferFailed, arg.Status)
+
+	// a transfer that runs once ends
+	scheduled.IntervalUnit = sql.NullString{}
+	arg = nextSchedule(scheduled, nil, policy, now)
+	require.Equal(t, ScheduledTransferCompleted, arg.Status)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	// monthly transfers keep the day of the start, or the last day of shorter months
+	next := nextOccurrence(start, IntervalMonth, 1, start)
+	require.Equal(t, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC), next)
+
+	next = nextOccurrence(start, IntervalMonth, 1, next)
+	require.Equal(t, time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC), next)
+
+	next = nextOccurrence(start, IntervalMonth, 2, start)
+	require.Equal(t, time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC), next)
+
+	next = nextOccurrence(start, IntervalDay, 3, start)
+	require.Equal(t, time.Date(2023, time.February, 3, 9, 0, 0, 0, time.UTC), next)
+
+	next = nextOccurrence(start, IntervalWeek, 1, start.AddDate(0, 0, 8))
+	require.Equal(t, time.Date(2023, time.February, 14, 9, 0, 0, 0, time.UTC), next)
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// statementPageSize is how many entries StatementTx reads at a time
+const statementPageSize = 500
+
+// StatementWriter renders a statement as StatementTx reads it
+type StatementWriter interface {
+	// Begin is called first, with the balances at the start and at the end of the period
+	Begin(opening int64, closing int64) error
+	// Entry is called for each entry of the period, oldest first
+	Entry(entry ListStatementEntriesRow) error
+	// End is called after the last entry
+	End() error
+}
+
+type StatementTxParams struct {
+	AccountID int64
+	// From and To are the period of the statement, [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// StatementTx reads the statement of the account a page of entries at a time and passes it to w,
+// so a long period isn't held in memory. It reads from a single snapshot of the ledger, so the closing
+// balance is the opening one plus the entries passed.
+func (store *SQLStore) StatementTx(ctx context.Context, arg StatementTxParams, w StatementWriter) error {
+	// without retries, w can't take back what it wrote
+	return store.execTx(ctx, func(q *Queries) error {
+		return statementTx(ctx, q, arg, w)
+	}, WithIsolation(sql.LevelRepeatableRead), WithReadOnly(), WithMaxRetries(0))
+}
+
+func statementTx(ctx context.Context, q *Queries, arg StatementTxParams, w StatementWriter) error {
+	opening, err := q.GetBalanceAt(ctx, GetBalanceAtParams{
+		AccountID: arg.AccountID,
+		At:        arg.From,
+	})
+	if err != nil {
+		return err
+	}
+
+	closing, err := q.GetBalanceAt(ctx, GetBalanceAtParams{
+		AccountID: arg.AccountID,
+		At:        arg.To,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.Begin(opening, closing)
+	if err != nil {
+		return err
+	}
+
+	page := ListStatementEntriesParams{
+		AccountID:   arg.AccountID,
+		CreatedFrom: arg.From,
+		CreatedTo:   arg.To,
+		Limit:       statementPageSize,
+	}
+	for {
+		entries, err := q.ListStatementEntries(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			err = w.Entry(entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < statementPageSize {
+			return w.End()
+		}
+
+		last := entries[len(entries)-1]
+		page.AfterCreatedAt = last.CreatedAt.Time
+		page.AfterID = last.ID
+	}
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingWriter keeps what StatementTx passes to it
+type recordingWriter struct {
+	opening int64
+	closing int64
+	entries []ListStatementEntriesRow
+	ended   bool
+}
+
+func (w *recordingWriter) Begin(opening int64, closing int64) error {
+	w.opening = opening
+	w.closing = closing
+	return nil
+}
+
+func (w *recordingWriter) Entry(entry ListStatementEntriesRow) error {
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func (w *recordingWriter) End() error {
+	w.ended = true
+	return nil
+}
+
+func TestStatementTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "USD")
+	require.NoError(t, err)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "USD")
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: account1.ID, Amount: 100})
+	require.NoError(t, err)
+
+	from := time.Now()
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account1.ID, Amount: 10})
+	require.NoError(t, err)
+
+	to := time.Now()
+
+	// after the period
+	_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: account1.ID, Amount: 5})
+	require.NoError(t, err)
+
+	w := &recordingWriter{}
+	err = store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account1.ID,
+		From:      from,
+		To:        to,
+	}, w)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(100), w.opening)
+	require.Equal(t, int64(50), w.closing)
+	require.True(t, w.ended)
+
+	require.Len(t, w.entries, 2)
+	require.Equal(t, result.FromEntry.ID, w.entries[0].ID)
+	require.Equal(t, int64(-40), w.entries[0].Amount)
+	require.Equal(t, result.Transfer.ID, w.entries[0].TransferID.Int64)
+	require.Equal(t, account2.ID, w.entries[0].CounterpartyAccountID)
+	require.False(t, w.entries[1].TransferID.Valid)
+	require.Equal(t, int64(-10), w.entries[1].Amount)
+
+	// the credit of the transfer names the other side
+	w = &recordingWriter{}
+	err = store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account2.ID,
+		From:      from,
+		To:        to,
+	}, w)
+	require.NoError(t, err)
+	require.Len(t, w.entries, 1)
+	require.Equal(t, account1.ID, w.entries[0].CounterpartyAccountID)
+	require.Equal(t, int64(40), w.closing-w.opening)
+}
+
+func TestStatementTxFeeEqualToAmount(t *testing.T) {
+	store := NewStore(testDb)
+
+	// a tier of its own, so the schedule doesn't charge the accounts of other tests
+	tier := util.RandomString(8)
+	_, err := testQueries.CreateFeeSchedule(context.Background(), CreateFeeScheduleParams{
+		Currency: "USD",
+		Tier:     sql.NullString{String: tier, Valid: true},
+		FlatFee:  40,
+	})
+	require.NoError(t, err)
+
+	user1, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account1, _, err := persistRandomAccount(t, user1, "USD")
+	require.NoError(t, err)
+	user2, _, err := persistRandomUser(t, "")
+	require.NoError(t, err)
+	account2, _, err := persistRandomAccount(t, user2, "USD")
+	require.NoError(t, err)
+	account1 = fundAccount(t, account1, 100)
+	_, err = testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account1.ID,
+		Tier: tier,
+	})
+	require.NoError(t, err)
+
+	from := time.Now()
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(40), result.Fee)
+	to := time.Now()
+
+	w := &recordingWriter{}
+	err = store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account1.ID,
+		From:      from,
+		To:        to,
+	}, w)
+	require.NoError(t, err)
+
+	// the same amount, only one of them is the transfer
+	require.Len(t, w.entries, 2)
+	require.Equal(t, result.FromEntry.ID, w.entries[0].ID)
+	require.False(t, w.entries[0].Fee)
+	require.Equal(t, account2.ID, w.entries[0].CounterpartyAccountID)
+	require.Equal(t, result.FeeEntry.ID, w.entries[1].ID)
+	require.True(t, w.entries[1].Fee)
+	require.Zero(t, w.entries[1].CounterpartyAccountID)
+	require.Equal(t, w.entries[0].Amount, w.entries[1].Amount)
+}
@@ -1,11 +1,24 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/homocode/bank_demo/util"
+)
+
+// ErrInsufficientFunds is returned by TransferTx when the available balance of the FromAccount
+// doesn't cover the amount to transfer
+var ErrInsufficientFunds = errors.New("insufficient funds")
 
 type TransferTxParams struct {
 	FromAccountId int64
 	ToAccountId   int64
 	Amount        int64
+	// QuoteID is the rate quote to apply on a cross-currency transfer, zero for same currency ones
+	QuoteID int64
 }
 
 type TransferTxResult struct {
@@ -14,65 +27,171 @@ type TransferTxResult struct {
 	ToAccount   Accounts
 	FromEntry   Entries
 	ToEntry     Entries
+	// Fee charged to the FromAccount in its currency, on top of the amount
+	Fee int64
+	// FeeEntry takes the fee out of the FromAccount, empty when there was no fee
+	FeeEntry Entries
+	// FeeRefund is the part of the fee of the reversed transfer returned to the ToAccount, on reversals only
+	FeeRefund int64
+	// FeeRefundEntry returns the FeeRefund to the ToAccount, empty when there was none
+	FeeRefundEntry Entries
 }
 
 // TransferTx performs a transfer between two accounts by creating a transfer record,
 // two entry records (money out FromAccount and money in ToAccount) and update accounts balance.
+// When arg.QuoteID is set the accounts can have different currencies, see fxTransferTx.
+// It fails with a *LimitExceededError when the amount is over a transfer limit of the FromAccount.
+// The fee of the FromAccount schedule is charged in the same transaction, see chargeFee.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
-		// Createa a transfer record to persist the amount and accounts involved
-		// in the transference
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountId,
-			ToAccountID:   arg.ToAccountId,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+		result, err = transferTx(ctx, q, arg)
+		return err
+	})
 
-		// Creates an entry record to persist the amount of money leaving the account
-		// where it is transferred from
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountId,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
+
+// transferTx runs the queries of a transfer using q, which must be bound to a transaction
+func transferTx(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	if arg.QuoteID != 0 {
+		return fxTransferTx(ctx, q, arg)
+	}
+
+	// Lock both accounts rows before anything else, so the balance of the FromAccount
+	// can't change until the transaction ends
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountId, arg.ToAccountId)
+	if err != nil {
+		return
+	}
+
+	// the sender pays the fee on top of the amount
+	fee, err := transferFee(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	return chargedTransfer(ctx, q, fromAccount, toAccount, arg, fee)
+}
+
+// chargedTransfer checks and books the transfer between the locked accounts and charges fee to the FromAccount
+func chargedTransfer(ctx context.Context, q *Queries, fromAccount Accounts, toAccount Accounts, arg TransferTxParams, fee int64) (result TransferTxResult, err error) {
+	err = checkStatus(fromAccount, toAccount)
+	if err != nil {
+		return
+	}
+
+	total, err := util.AddAmounts(arg.Amount, fee)
+	if err != nil {
+		return
+	}
+
+	err = checkFunds(ctx, q, fromAccount, total, "transfer")
+	if err != nil {
+		return
+	}
+
+	err = checkLimits(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	result, err = bookTransfer(ctx, q, arg)
+	if err != nil || fee == 0 {
+		return
+	}
 
-		// Creates an entry record to persist the amount of money entering the account
-		// where it is transferred to
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountId,
-			Amount:    arg.Amount,
-		})
+	result.Fee = fee
+	result.FromAccount, result.FeeEntry, err = chargeFee(ctx, q, result.Transfer, fromAccount.Currency, fee)
+	return
+}
+
+// bookTransfer records the transfer, its entries and updates the balances, the checks are up to the caller
+func bookTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	// Createa a transfer record to persist the amount and accounts involved
+	// in the transference
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountId,
+		ToAccountID:   arg.ToAccountId,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	// Creates an entry record to persist the amount of money leaving the account
+	// where it is transferred from
+	result.FromEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  arg.FromAccountId,
+		Amount:     -arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	// Creates an entry record to persist the amount of money entering the account
+	// where it is transferred to
+	result.ToEntry, err = q.CreateTransferEntry(ctx, CreateTransferEntryParams{
+		AccountID:  arg.ToAccountId,
+		Amount:     arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return
+	}
+
+	// Update accounts balance
+	// Update in a specific order, in this case the account with the smaller id first, to update in
+	// a consistent order to prevent a deadlock
+	if arg.FromAccountId < arg.ToAccountId {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountId, -arg.Amount, arg.ToAccountId, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountId, arg.Amount, arg.FromAccountId, -arg.Amount)
+	}
+
+	return
+}
+
+// checkFunds fails with ErrInsufficientFunds when the available balance of the account doesn't
+// cover amount. The available balance is the balance minus the funds reserved by active holds,
+// the account must be locked so neither changes before the transaction ends.
+func checkFunds(ctx context.Context, q *Queries, account Accounts, amount int64, operation string) error {
+	held, err := q.SumActiveHolds(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if available := account.Balance - held; available < amount {
+		return fmt.Errorf("%w: account %d has available balance %d, %s amount %d",
+			ErrInsufficientFunds, account.ID, available, operation, amount)
+	}
+
+	return nil
+}
+
+// lockAccounts selects both accounts for update, the account with the smaller id first,
+// to lock them in a consistent order and prevent a deadlock.
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Accounts, toAccount Accounts, err error) {
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
 		if err != nil {
-			return err
+			return
 		}
 
-		// Update accounts balance
-		// Update in a specific order, in this case the account with the smaller id first, to update in
-		// a consistent order to prevent a deadlock
-		if arg.FromAccountId < arg.ToAccountId {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountId, -arg.Amount, arg.ToAccountId, arg.Amount)
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountId, arg.Amount, arg.FromAccountId, -arg.Amount)
-			if err != nil {
-				return err
-			}
-		}
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+		return
+	}
 
-		return nil
-	})
+	toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+	if err != nil {
+		return
+	}
 
-	return result, err
+	fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+	return
 }
 
 func addMoney(
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Period of a transfer limit, days and months start at midnight UTC
+const (
+	LimitPerTransaction = "transaction"
+	LimitDaily          = "day"
+	LimitMonthly        = "month"
+)
+
+// ErrLimitExceeded is the error a *LimitExceededError matches with errors.Is
+var ErrLimitExceeded = errors.New("transfer limit exceeded")
+
+// LimitExceededError is returned by TransferTx when the amount is over the remaining allowance of a limit
+type LimitExceededError struct {
+	Allowance TransferAllowance
+	Amount    int64
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%v: limit %d of %d per %s, remaining %d, transfer amount %d",
+		ErrLimitExceeded, e.Allowance.Limit.ID, e.Allowance.Limit.Amount, e.Allowance.Limit.Period,
+		e.Allowance.Remaining, e.Amount)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// TransferAllowance is how much of a limit was used in its current period and how much is left
+type TransferAllowance struct {
+	Limit     TransferLimits `json:"limit"`
+	Used      int64          `json:"used"`
+	Remaining int64          `json:"remaining"`
+}
+
+// TransferAllowances lists the limits that apply to transfers out of the account with what's left of them
+func (store *SQLStore) TransferAllowances(ctx context.Context, account Accounts) ([]TransferAllowance, error) {
+	return transferAllowances(ctx, store.Queries, account, time.Now())
+}
+
+// checkLimits fails with a *LimitExceededError when amount is over the remaining allowance of any limit
+// of the account. The account must be locked, so no transfer out of it is booked before the transaction
+// ends.
+func checkLimits(ctx context.Context, q *Queries, account Accounts, amount int64) error {
+	allowances, err := transferAllowances(ctx, q, account, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, allowance := range allowances {
+		if amount > allowance.Remaining {
+			return &LimitExceededError{Allowance: allowance, Amount: amount}
+		}
+	}
+
+	return nil
+}
+
+// transferAllowances gets the limits that apply to the account and sums what was transferred
+// in the period of each at now
+func transferAllowances(ctx context.Context, q *Queries, account Accounts, now time.Time) ([]TransferAllowance, error) {
+	limits, err := q.ListTransferLimits(ctx, ListTransferLimitsParams{
+		Currency:  account.Currency,
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allowances := make([]TransferAllowance, 0, len(limits))
+	for _, limit := range limits {
+		allowance := TransferAllowance{Limit: limit}
+
+		if limit.Period != LimitPerTransaction {
+			allowance.Used, err = q.SumAccountTransfers(ctx, SumAccountTransfersParams{
+				AccountID: account.ID,
+				Since:     periodStart(limit.Period, now),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		allowance.Remaining = limit.Amount - allowance.Used
+		if allowance.Remaining < 0 {
+			allowance.Remaining = 0
+		}
+		allowances = append(allowances, allowance)
+	}
+
+	return allowances, nil
+}
+
+// periodStart is the midnight UTC that starts the day or month of now
+func periodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	if period == LimitMonthly {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func persistTransferLimit(t *testing.T, arg CreateTransferLimitParams) TransferLimits {
+	t.Helper()
+
+	limit, err := testQueries.CreateTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+
+	return limit
+}
+
+func TestTransferTxAccountLimits(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "USD")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "USD")
+	account1 = fundAccount(t, account1, 100)
+
+	accountID := sql.NullInt64{Int64: account1.ID, Valid: true}
+	persistTransferLimit(t, CreateTransferLimitParams{AccountID: accountID, Currency: "USD", Period: LimitPerTransaction, Amount: 40})
+	persistTransferLimit(t, CreateTransferLimitParams{AccountID: accountID, Currency: "USD", Period: LimitDaily, Amount: 60})
+
+	// over the per transaction limit
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        41,
+	})
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerTransaction, limitErr.Allowance.Limit.Period)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+
+	// the daily limit has 20 left
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        21,
+	})
+	require.ErrorIs(t, err, ErrLimitExceeded)
+
+	allowances, err := store.TransferAllowances(context.Background(), account1)
+	require.NoError(t, err)
+	for _, allowance := range allowances {
+		if allowance.Limit.Period == LimitDaily {
+			require.Equal(t, int64(40), allowance.Used)
+			require.Equal(t, int64(20), allowance.Remaining)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 23, 30, 0, 0, time.FixedZone("UTC-3", -3*60*60))
+
+	require.Equal(t, time.Date(2023, time.March, 11, 0, 0, 0, 0, time.UTC), periodStart(LimitDaily, now))
+	require.Equal(t, time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), periodStart(LimitMonthly, now))
+}
@@ -17,15 +17,19 @@ func TestTransferTx(t *testing.T) {
 	account2, _, _ := persistRandomAccount(t, user2, "")
 	amount := 400
 
+	// Run n concurrent TransferTx. Each one in one of
+	// the n go rutines.
+	n := 5
+
+	// fund account1 so it can afford all the transfers
+	account1 = fundAccount(t, account1, int64(amount*n))
+
 	arg := TransferTxParams{
 		FromAccountId: account1.ID,
 		ToAccountId:   account2.ID,
 		Amount:        int64(amount),
 	}
 
-	// Run n concurrent TransferTx. Each one in one of
-	// the n go rutines.
-	n := 5
 	results := make(chan TransferTxResult, n)
 	errors := make(chan error, n)
 	var wg = &sync.WaitGroup{}
@@ -127,6 +131,10 @@ func TestTransferTxDeadlock(t *testing.T) {
 	// Run n concurrent TransferTx. Each one in one of
 	// the n go rutines.
 	n := 10
+
+	// fund both accounts so any execution order of the transfers is affordable
+	account1 = fundAccount(t, account1, int64(amount*n))
+	account2 = fundAccount(t, account2, int64(amount*n))
 	errors := make(chan error, n)
 	var wg = &sync.WaitGroup{}
 	wg.Add(n)
@@ -172,3 +180,72 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDb)
+
+	user1, _, _ := persistRandomUser(t, "")
+	account1, _, _ := persistRandomAccount(t, user1, "")
+	user2, _, _ := persistRandomUser(t, "")
+	account2, _, _ := persistRandomAccount(t, user2, "")
+	amount := int64(400)
+
+	// Run n concurrent TransferTx, account1 can only afford k of them
+	n := 10
+	k := 3
+	account1 = fundAccount(t, account1, amount*int64(k)-account1.Balance)
+
+	arg := TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+	}
+
+	errs := make(chan error, n)
+	var wg = &sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := store.TransferTx(context.Background(), arg)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded, rejected int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+		rejected++
+	}
+
+	require.Equal(t, k, succeeded)
+	require.Equal(t, n-k, rejected)
+
+	// the account is never overdrawn
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+amount*int64(k), updatedAccount2.Balance)
+}
+
+// fundAccount adds amount to the account balance and returns the updated account
+func fundAccount(t *testing.T, account Accounts, amount int64) Accounts {
+	t.Helper()
+
+	account, err := testQueries.AddAmountToAccountBalance(context.Background(), AddAmountToAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	require.NoError(t, err)
+
+	return account
+}
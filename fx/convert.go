@@ -0,0 +1,52 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrAmountOverflow is returned when a converted amount doesn't fit in an int64
+var ErrAmountOverflow = errors.New("converted amount overflows")
+
+// Convert applies the rate to the amount. The converted amount is rounded down, so the bank never
+// credits more than the exact value. The residue is what was rounded off, scaled by RateScale:
+// amount*rate == converted*RateScale + residue.
+func Convert(amount int64, rate int64) (converted int64, residue int64, err error) {
+	if amount < 0 || rate <= 0 {
+		return 0, 0, fmt.Errorf("invalid conversion of amount %d with rate %d", amount, rate)
+	}
+
+	exact := new(big.Int).Mul(big.NewInt(amount), big.NewInt(rate))
+	quo, rem := new(big.Int).QuoRem(exact, big.NewInt(RateScale), new(big.Int))
+	if !quo.IsInt64() {
+		return 0, 0, ErrAmountOverflow
+	}
+
+	return quo.Int64(), rem.Int64(), nil
+}
+
+// ParseRate parses a positive decimal rate like "350.25" into a rate scaled by RateScale
+func ParseRate(value string) (int64, error) {
+	integer, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	decimals := len(strconv.Itoa(RateScale)) - 1
+	if integer == "" || len(fraction) > decimals {
+		return 0, fmt.Errorf("invalid rate %q: must be a decimal with at most %d decimals", value, decimals)
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	rate, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid rate %q: must be a positive decimal", value)
+	}
+
+	return rate, nil
+}
+
+// FormatRate formats a rate scaled by RateScale as a decimal string
+func FormatRate(rate int64) string {
+	decimals := len(strconv.Itoa(RateScale)) - 1
+	return fmt.Sprintf("%d.%0*d", rate/RateScale, decimals, rate%RateScale)
+}
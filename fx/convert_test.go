@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name      string
+		amount    int64
+		rate      int64
+		converted int64
+		residue   int64
+	}{
+		{name: "SameCurrency", amount: 1000, rate: RateScale, converted: 1000, residue: 0},
+		{name: "Exact", amount: 1000, rate: 350_500_000, converted: 350_500, residue: 0},
+		{name: "RoundedDown", amount: 333, rate: 1_085_000, converted: 361, residue: 305_000},
+		{name: "LessThanOneUnit", amount: 1, rate: 2_850, converted: 0, residue: 2_850},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			converted, residue, err := Convert(tc.amount, tc.rate)
+			require.NoError(t, err)
+			require.Equal(t, tc.converted, converted)
+			require.Equal(t, tc.residue, residue)
+
+			// nothing is lost, the residue is what was rounded off
+			require.Equal(t, tc.amount*tc.rate, converted*RateScale+residue)
+		})
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	_, _, err := Convert(math.MaxInt64, 2*RateScale)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestConvertInvalid(t *testing.T) {
+	_, _, err := Convert(-1, RateScale)
+	require.Error(t, err)
+
+	_, _, err = Convert(1, 0)
+	require.Error(t, err)
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("350.25")
+	require.NoError(t, err)
+	require.Equal(t, int64(350_250_000), rate)
+	require.Equal(t, "350.250000", FormatRate(rate))
+
+	rate, err = ParseRate("0.000001")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rate)
+
+	for _, invalid := range []string{"", "abc", "-1", "0", "1.0000001", ".5"} {
+		_, err = ParseRate(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,19 @@
+package fx
+
+import (
+	"context"
+	"errors"
+)
+
+// RateScale is the fixed point scale of the rates, a rate of 1.5 is represented as 1_500_000.
+// Rates are expressed in minor units of the target currency per minor unit of the source currency.
+const RateScale = 1_000_000
+
+// ErrRateNotFound is returned when the provider has no rate for the currency pair
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ExchangeRateProvider is an interface for getting exchange rates between currencies
+type ExchangeRateProvider interface {
+	// Rate returns the rate to convert from one currency to another, scaled by RateScale
+	Rate(ctx context.Context, from string, to string) (int64, error)
+}
@@ -0,0 +1,80 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticProvider is an ExchangeRateProvider with a fixed set of rates
+type StaticProvider struct {
+	rates map[string]int64
+}
+
+// NewStaticProvider creates a StaticProvider from rates given as decimal strings keyed by
+// "FROM/TO" pairs, e.g. {"USD/ARS": "350.5"}. The inverse of each pair is derived when missing.
+func NewStaticProvider(rates map[string]string) (*StaticProvider, error) {
+	provider := &StaticProvider{rates: make(map[string]int64, len(rates)*2)}
+
+	for pair, value := range rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid currency pair %q: must be FROM/TO", pair)
+		}
+
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %s: %w", pair, err)
+		}
+
+		provider.rates[pairKey(from, to)] = rate
+	}
+
+	// derive the missing inverse rates
+	for pair, rate := range provider.rates {
+		from, to, _ := strings.Cut(pair, "/")
+		inverse := pairKey(to, from)
+		if _, ok := provider.rates[inverse]; !ok {
+			provider.rates[inverse] = (RateScale*RateScale + rate/2) / rate
+		}
+	}
+
+	return provider, nil
+}
+
+// NewFileProvider creates a StaticProvider with the rates of a JSON file,
+// see NewStaticProvider for the format
+func NewFileProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read exchange rates file: %w", err)
+	}
+
+	var rates map[string]string
+	err = json.Unmarshal(data, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse exchange rates file: %w", err)
+	}
+
+	return NewStaticProvider(rates)
+}
+
+// Rate returns the rate to convert from one currency to another, scaled by RateScale
+func (provider *StaticProvider) Rate(ctx context.Context, from string, to string) (int64, error) {
+	if from == to {
+		return RateScale, nil
+	}
+
+	rate, ok := provider.rates[pairKey(from, to)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+
+	return rate, nil
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
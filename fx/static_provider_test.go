@@ -0,0 +1,64 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider(map[string]string{
+		"USD/ARS": "350",
+		"EUR/USD": "1.08",
+		"USD/EUR": "0.92",
+	})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "ARS")
+	require.NoError(t, err)
+	require.Equal(t, int64(350*RateScale), rate)
+
+	// derived inverse rate
+	rate, err = provider.Rate(context.Background(), "ARS", "USD")
+	require.NoError(t, err)
+	require.Equal(t, int64(2_857), rate)
+
+	// explicit rates are not overridden by the derived ones
+	rate, err = provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, int64(920_000), rate)
+
+	rate, err = provider.Rate(context.Background(), "EUR", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, int64(RateScale), rate)
+
+	_, err = provider.Rate(context.Background(), "EUR", "ARS")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestStaticProviderInvalidPair(t *testing.T) {
+	_, err := NewStaticProvider(map[string]string{"USDARS": "350"})
+	require.Error(t, err)
+
+	_, err = NewStaticProvider(map[string]string{"USD/ARS": "-350"})
+	require.Error(t, err)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"USD/ARS": "350.5"}`), 0o600)
+	require.NoError(t, err)
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "ARS")
+	require.NoError(t, err)
+	require.Equal(t, int64(350_500_000), rate)
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
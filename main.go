@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
 
 	api "github.com/homocode/bank_demo/api"
 	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/pain"
 	"github.com/homocode/bank_demo/util"
+	"github.com/homocode/bank_demo/worker"
 	_ "github.com/lib/pq"
 )
 
@@ -21,10 +25,92 @@ func main() {
 	}
 
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	reconciler := worker.NewReconciler(config, store)
+
+	// the currencies are loaded before serving or importing, the validation of the requests
+	// and the amounts of the payment files need them
+	currencies := util.NewCurrencyRegistry(nil)
+	currencyRefresher := worker.NewCurrencyRefresher(config, store, currencies)
+	err = currencyRefresher.Refresh(context.Background())
+	if err != nil {
+		log.Fatal("Can't load the currencies", err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			reconcile(reconciler)
+			return
+		case "import-payments":
+			importPayments(pain.NewImporter(config, store), currencies, os.Args[2:])
+			return
+		default:
+			log.Fatalf("Unknown command %q, the commands are reconcile and import-payments", os.Args[1])
+		}
+	}
+
+	scheduler := worker.NewTransferScheduler(config, store)
+	go scheduler.Start(context.Background())
+	go reconciler.Start(context.Background())
+
+	snapshotter := worker.NewBalanceSnapshotter(config, store)
+	go snapshotter.Start(context.Background())
+
+	go currencyRefresher.Start(context.Background())
+
+	server, err := api.NewServer(config, store, currencies)
+	if err != nil {
+		log.Fatal("Can't create server", err)
+	}
+
 	err = server.Start(config.ServerAddress)
 
 	if err != nil {
 		log.Fatal("Can't start server", err)
 	}
 }
+
+// reconcile checks the ledger once, it exits with 1 when there are discrepancies
+func reconcile(reconciler *worker.Reconciler) {
+	result, err := reconciler.Reconcile(context.Background())
+	if err != nil {
+		log.Fatal("Can't reconcile the ledger", err)
+	}
+
+	report := result.Report
+	log.Printf("Reconciliation report %d: %d accounts and %d transfers checked, %d discrepancies",
+		report.ID, report.AccountsChecked, report.TransfersChecked, report.Discrepancies)
+	if report.Discrepancies > 0 {
+		os.Exit(1)
+	}
+}
+
+// importPayments books the transfers of a pain.001 file, out of any account, and writes the pain.002
+// with their status to stdout. It exits with 1 when the file is invalid or a transfer was rejected.
+func importPayments(importer *pain.Importer, currencies *util.CurrencyRegistry, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: import-payments <pain.001 file>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		log.Fatal("Can't open the payment file ", err)
+	}
+	defer file.Close()
+
+	initiation, err := pain.Parse(file, currencies)
+	if err != nil {
+		log.Fatal("Can't import the payment file ", err)
+	}
+
+	report := importer.Import(context.Background(), initiation, "")
+	_, err = report.WriteTo(os.Stdout)
+	if err != nil {
+		log.Fatal("Can't write the status report ", err)
+	}
+
+	log.Printf("Payment file %s: %d transfers, status %s", initiation.MessageID, report.NumberOfTransfers, report.Status)
+	if report.Status != pain.StatusAccepted {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,204 @@
+package pain
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+)
+
+// requestPath is the path of the idempotency keys of the imported transfers
+const requestPath = "pain.001"
+
+var (
+	errUnknownAccount   = errors.New("unknown account")
+	errAccountNotOwned  = errors.New("account doesn't belong to the authenticated user")
+	errCurrencyMismatch = errors.New("currency mismatch")
+)
+
+type Store interface {
+	GetAccount(ctx context.Context, id int64) (db.Accounts, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (db.Accounts, error)
+	TransferTxIdempotent(ctx context.Context, idem db.IdempotencyParams, arg db.TransferTxParams) (db.TransferTxResult, bool, error)
+}
+
+// Importer books the credit transfers of pain.001 files
+type Importer struct {
+	store     Store
+	retention time.Duration
+}
+
+func NewImporter(config util.Config, store Store) *Importer {
+	return &Importer{
+		store:     store,
+		retention: config.IdempotencyKeyRetention,
+	}
+}
+
+// Import books each transfer of the initiation on its own, the ones rejected don't stop the rest.
+// When owner isn't empty the debtor accounts must belong to it.
+//
+// The transfers are idempotent by MsgId and EndToEndId, so importing a file again books only the
+// transfers rejected the first time and reports the others as they were.
+func (im *Importer) Import(ctx context.Context, in Initiation, owner string) StatusReport {
+	report := StatusReport{
+		MessageID:         truncate("STS-"+in.MessageID, maxTextLength),
+		CreatedAt:         time.Now(),
+		OriginalMessageID: in.MessageID,
+		NumberOfTransfers: in.NumberOfTransfers(),
+	}
+	accounts := make(map[string]db.Accounts)
+
+	statuses := make([]string, len(in.Payments))
+	for i, payment := range in.Payments {
+		status := im.importPayment(ctx, accounts, in.MessageID, payment, owner)
+		report.Payments = append(report.Payments, status)
+		statuses[i] = status.Status
+	}
+	report.Status = groupStatus(statuses)
+
+	return report
+}
+
+func (im *Importer) importPayment(ctx context.Context, accounts map[string]db.Accounts, messageID string, payment Payment, owner string) PaymentStatus {
+	status := PaymentStatus{PaymentID: payment.ID}
+
+	debtor, err := im.account(ctx, accounts, payment.DebtorAccount)
+	if err == nil && owner != "" && debtor.Owner != owner {
+		err = errAccountNotOwned
+	}
+
+	statuses := make([]string, len(payment.Transfers))
+	for i, transfer := range payment.Transfers {
+		transferStatus := TransferStatus{
+			InstructionID: transfer.InstructionID,
+			EndToEndID:    transfer.EndToEndID,
+			Status:        StatusAccepted,
+		}
+
+		transferErr := err
+		if transferErr == nil {
+			transferStatus.TransferID, transferErr = im.transfer(ctx, accounts, messageID, debtor, transfer)
+		}
+		if transferErr != nil {
+			transferStatus.Status = StatusRejected
+			transferStatus.Reason = reason(transferErr)
+			transferStatus.AdditionalInfo = transferErr.Error()
+		}
+
+		status.Transfers = append(status.Transfers, transferStatus)
+		statuses[i] = transferStatus.Status
+	}
+	status.Status = groupStatus(statuses)
+
+	return status
+}
+
+// transfer books the transfer out of the debtor account and returns its id
+func (im *Importer) transfer(ctx context.Context, accounts map[string]db.Accounts, messageID string, debtor db.Accounts, transfer CreditTransfer) (int64, error) {
+	creditor, err := im.account(ctx, accounts, transfer.CreditorAccount)
+	if err != nil {
+		return 0, err
+	}
+
+	// there are no rate quotes in a file, both accounts must have the currency of the transfer
+	for _, account := range []db.Accounts{debtor, creditor} {
+		if account.Currency != transfer.Currency {
+			return 0, fmt.Errorf("account %d, %w, want: %s got: %s", account.ID, errCurrencyMismatch, transfer.Currency, account.Currency)
+		}
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountId: debtor.ID,
+		ToAccountId:   creditor.ID,
+		Amount:        transfer.Amount,
+	}
+	idem, err := im.idempotencyParams(debtor.Owner, messageID, transfer.EndToEndID, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	result, _, err := im.store.TransferTxIdempotent(ctx, idem, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Transfer.ID, nil
+}
+
+// account resolves an account of the file, caching the accounts already resolved. The accounts are
+// identified by their account number or by their id, the way the camt.053 statements write them.
+func (im *Importer) account(ctx context.Context, accounts map[string]db.Accounts, number string) (db.Accounts, error) {
+	if account, ok := accounts[number]; ok {
+		return account, nil
+	}
+
+	var account db.Accounts
+	var err error
+	if accountNumber := util.NormalizeAccountNumber(number); util.IsValidAccountNumber(accountNumber) {
+		account, err = im.store.GetAccountByNumber(ctx, accountNumber)
+	} else {
+		id, parseErr := strconv.ParseInt(number, 10, 64)
+		if parseErr != nil {
+			return account, fmt.Errorf("%w %s", errUnknownAccount, number)
+		}
+		account, err = im.store.GetAccount(ctx, id)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, fmt.Errorf("%w %s", errUnknownAccount, number)
+		}
+		return account, err
+	}
+
+	accounts[number] = account
+	return account, nil
+}
+
+func (im *Importer) idempotencyParams(owner string, messageID string, endToEndID string, arg db.TransferTxParams) (db.IdempotencyParams, error) {
+	payload, err := json.Marshal(arg)
+	if err != nil {
+		return db.IdempotencyParams{}, err
+	}
+	hash := sha256.Sum256(payload)
+
+	return db.IdempotencyParams{
+		Owner:       owner,
+		Key:         messageID + "/" + endToEndID,
+		RequestPath: requestPath,
+		RequestHash: hex.EncodeToString(hash[:]),
+		Retention:   im.retention,
+	}, nil
+}
+
+// reason is the code of the reason a transfer was rejected for
+func reason(err error) string {
+	switch {
+	case errors.Is(err, errUnknownAccount):
+		return ReasonIncorrectAccount
+	case errors.Is(err, errAccountNotOwned):
+		return ReasonForbidden
+	case errors.Is(err, errCurrencyMismatch):
+		return ReasonCurrencyNotAllowed
+	case errors.Is(err, db.ErrAccountClosed):
+		return ReasonClosedAccount
+	case errors.Is(err, db.ErrAccountFrozen):
+		return ReasonBlockedAccount
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return ReasonInsufficientFunds
+	case errors.Is(err, db.ErrLimitExceeded), errors.Is(err, util.ErrAmountOverflow):
+		return ReasonAmountNotAllowed
+	case errors.Is(err, db.ErrIdempotencyKeyReused):
+		return ReasonDuplication
+	}
+
+	return ReasonNarrative
+}
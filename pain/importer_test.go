@@ -0,0 +1,220 @@
+package pain
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore books the transfers the accounts have balance for, replaying the ones with a key already used
+type fakeStore struct {
+	accounts  map[int64]db.Accounts
+	keys      map[string]db.TransferTxResult
+	transfers int64
+}
+
+func newFakeStore(accounts ...db.Accounts) *fakeStore {
+	store := &fakeStore{
+		accounts: make(map[int64]db.Accounts),
+		keys:     make(map[string]db.TransferTxResult),
+	}
+	for _, account := range accounts {
+		store.accounts[account.ID] = account
+	}
+	return store
+}
+
+func (f *fakeStore) GetAccount(ctx context.Context, id int64) (db.Accounts, error) {
+	account, ok := f.accounts[id]
+	if !ok {
+		return account, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (f *fakeStore) GetAccountByNumber(ctx context.Context, accountNumber string) (db.Accounts, error) {
+	for _, account := range f.accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return db.Accounts{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) TransferTxIdempotent(ctx context.Context, idem db.IdempotencyParams, arg db.TransferTxParams) (db.TransferTxResult, bool, error) {
+	if result, ok := f.keys[idem.Owner+idem.Key]; ok {
+		return result, true, nil
+	}
+
+	from := f.accounts[arg.FromAccountId]
+	if from.Balance < arg.Amount {
+		return db.TransferTxResult{}, false, fmt.Errorf("%w: account %d", db.ErrInsufficientFunds, from.ID)
+	}
+	from.Balance -= arg.Amount
+	f.accounts[from.ID] = from
+
+	f.transfers++
+	result := db.TransferTxResult{Transfer: db.Transfers{ID: f.transfers}}
+	f.keys[idem.Owner+idem.Key] = result
+	return result, false, nil
+}
+
+func testInitiation(debtor string, transfers ...CreditTransfer) Initiation {
+	return Initiation{
+		MessageID: "MSG-1",
+		Payments:  []Payment{{ID: "PMT-1", DebtorAccount: debtor, Transfers: transfers}},
+	}
+}
+
+func transferTo(endToEndID string, creditor string, amount int64, currency string) CreditTransfer {
+	return CreditTransfer{EndToEndID: endToEndID, Amount: amount, Currency: currency, CreditorAccount: creditor}
+}
+
+func TestImport(t *testing.T) {
+	owner := util.RandomOwner()
+	store := newFakeStore(
+		db.Accounts{ID: 1, Owner: owner, Balance: 100, Currency: "USD"},
+		db.Accounts{ID: 2, Owner: util.RandomOwner(), Currency: "USD"},
+		db.Accounts{ID: 3, Owner: util.RandomOwner(), Currency: "EUR"},
+	)
+	importer := NewImporter(util.Config{}, store)
+
+	in := testInitiation("1",
+		transferTo("E2E-1", "2", 60, "USD"),
+		transferTo("E2E-2", "2", 60, "USD"),
+		transferTo("E2E-3", "3", 10, "USD"),
+		transferTo("E2E-4", "9", 10, "USD"),
+		transferTo("E2E-5", "2", 40, "USD"),
+	)
+	report := importer.Import(context.Background(), in, owner)
+
+	require.Equal(t, "MSG-1", report.OriginalMessageID)
+	require.Equal(t, 5, report.NumberOfTransfers)
+	require.Equal(t, StatusPartial, report.Status)
+	require.Equal(t, StatusPartial, report.Payments[0].Status)
+
+	transfers := report.Payments[0].Transfers
+	require.Equal(t, TransferStatus{EndToEndID: "E2E-1", Status: StatusAccepted, TransferID: 1}, transfers[0])
+	require.Equal(t, ReasonInsufficientFunds, transfers[1].Reason)
+	require.Equal(t, ReasonCurrencyNotAllowed, transfers[2].Reason)
+	require.Equal(t, ReasonIncorrectAccount, transfers[3].Reason)
+	require.Equal(t, StatusAccepted, transfers[4].Status)
+	for _, transfer := range transfers[1:4] {
+		require.Equal(t, StatusRejected, transfer.Status)
+		require.NotEmpty(t, transfer.AdditionalInfo)
+	}
+
+	// importing it again replays the transfers booked
+	store.accounts[1] = db.Accounts{ID: 1, Owner: owner, Balance: 100, Currency: "USD"}
+	report = importer.Import(context.Background(), in, owner)
+	transfers = report.Payments[0].Transfers
+	require.Equal(t, int64(1), transfers[0].TransferID)
+	require.Equal(t, StatusAccepted, transfers[1].Status)
+	require.Equal(t, int64(3), transfers[1].TransferID)
+	require.Equal(t, int64(2), transfers[4].TransferID)
+}
+
+func TestImportDebtorAccount(t *testing.T) {
+	owner := util.RandomOwner()
+	store := newFakeStore(
+		db.Accounts{ID: 1, Owner: owner, Balance: 100, Currency: "USD"},
+		db.Accounts{ID: 2, Owner: util.RandomOwner(), Balance: 100, Currency: "USD"},
+	)
+	importer := NewImporter(util.Config{}, store)
+
+	// someone else's account
+	report := importer.Import(context.Background(), testInitiation("2", transferTo("E2E-1", "1", 10, "USD")), owner)
+	require.Equal(t, StatusRejected, report.Status)
+	require.Equal(t, ReasonForbidden, report.Payments[0].Transfers[0].Reason)
+
+	// without an owner any account can pay
+	report = importer.Import(context.Background(), testInitiation("2", transferTo("E2E-1", "1", 10, "USD")), "")
+	require.Equal(t, StatusAccepted, report.Status)
+
+	report = importer.Import(context.Background(), testInitiation("ACME", transferTo("E2E-2", "1", 10, "USD")), "")
+	require.Equal(t, StatusRejected, report.Status)
+	require.Equal(t, ReasonIncorrectAccount, report.Payments[0].Transfers[0].Reason)
+}
+
+func TestImportAccountNumbers(t *testing.T) {
+	owner := util.RandomOwner()
+	debtor := db.Accounts{ID: 1, Owner: owner, Balance: 100, Currency: "USD", AccountNumber: util.RandomAccountNumber()}
+	creditor := db.Accounts{ID: 2, Owner: util.RandomOwner(), Currency: "USD", AccountNumber: util.RandomAccountNumber()}
+	store := newFakeStore(debtor, creditor)
+	importer := NewImporter(util.Config{}, store)
+
+	// a typo doesn't fall back to ids
+	typo := creditor.AccountNumber[:15] + string('0'+(creditor.AccountNumber[15]-'0'+1)%10)
+	in := testInitiation(util.FormatAccountNumber(debtor.AccountNumber),
+		transferTo("E2E-1", creditor.AccountNumber, 10, "USD"),
+		transferTo("E2E-2", typo, 10, "USD"),
+	)
+	report := importer.Import(context.Background(), in, owner)
+
+	transfers := report.Payments[0].Transfers
+	require.Equal(t, StatusAccepted, transfers[0].Status)
+	require.Equal(t, ReasonIncorrectAccount, transfers[1].Reason)
+	require.Equal(t, int64(90), store.accounts[debtor.ID].Balance)
+}
+
+func TestStatusReportWriteTo(t *testing.T) {
+	report := StatusReport{
+		MessageID:         "STS-MSG-1",
+		OriginalMessageID: "MSG-1",
+		NumberOfTransfers: 2,
+		Status:            StatusPartial,
+		Payments: []PaymentStatus{{
+			PaymentID: "PMT-1",
+			Status:    StatusPartial,
+			Transfers: []TransferStatus{
+				{InstructionID: "I-1", EndToEndID: "E2E-1", Status: StatusAccepted, TransferID: 7},
+				{EndToEndID: "E2E-2", Status: StatusRejected, Reason: ReasonInsufficientFunds, AdditionalInfo: "insufficient funds"},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	_, err := report.WriteTo(&buf)
+	require.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.002.001.03 Document"`
+		Report  struct {
+			MessageID   string `xml:"GrpHdr>MsgId"`
+			Original    string `xml:"OrgnlGrpInfAndSts>OrgnlMsgId"`
+			MessageName string `xml:"OrgnlGrpInfAndSts>OrgnlMsgNmId"`
+			Status      string `xml:"OrgnlGrpInfAndSts>GrpSts"`
+			Transfers   []struct {
+				EndToEndID  string `xml:"OrgnlEndToEndId"`
+				Status      string `xml:"TxSts"`
+				Reason      string `xml:"StsRsnInf>Rsn>Cd"`
+				ServicerRef string `xml:"AcctSvcrRef"`
+			} `xml:"OrgnlPmtInfAndSts>TxInfAndSts"`
+		} `xml:"CstmrPmtStsRpt"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	require.Equal(t, "STS-MSG-1", doc.Report.MessageID)
+	require.Equal(t, "MSG-1", doc.Report.Original)
+	require.Equal(t, Pain001Version, doc.Report.MessageName)
+	require.Equal(t, StatusPartial, doc.Report.Status)
+	require.Len(t, doc.Report.Transfers, 2)
+	require.Equal(t, "7", doc.Report.Transfers[0].ServicerRef)
+	require.Empty(t, doc.Report.Transfers[0].Reason)
+	require.Equal(t, ReasonInsufficientFunds, doc.Report.Transfers[1].Reason)
+	require.Empty(t, doc.Report.Transfers[1].ServicerRef)
+}
+
+func TestGroupStatus(t *testing.T) {
+	require.Equal(t, StatusAccepted, groupStatus([]string{StatusAccepted, StatusAccepted}))
+	require.Equal(t, StatusRejected, groupStatus([]string{StatusRejected, StatusRejected}))
+	require.Equal(t, StatusPartial, groupStatus([]string{StatusAccepted, StatusRejected}))
+	require.Equal(t, StatusPartial, groupStatus([]string{StatusAccepted, StatusPartial}))
+}
@@ -0,0 +1,295 @@
+package pain
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/homocode/bank_demo/util"
+)
+
+// Pain001Version is the version of the customer credit transfer initiations Parse reads
+const Pain001Version = "pain.001.001.03"
+
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:" + Pain001Version
+
+// maxTextLength is the length of the identifications of the messages, Max35Text in the schema
+const maxTextLength = 35
+
+// ErrInvalidFile is returned by Parse for a file that isn't a valid pain.001, none of its
+// transfers is booked
+var ErrInvalidFile = errors.New("invalid pain.001 file")
+
+// Initiation is a customer credit transfer initiation, a batch of payments of a customer
+type Initiation struct {
+	MessageID string
+	Payments  []Payment
+}
+
+// Payment is a group of credit transfers out of the same account
+type Payment struct {
+	ID            string
+	DebtorAccount string
+	Transfers     []CreditTransfer
+}
+
+// CreditTransfer is an instruction to pay Amount, in minor units, to the CreditorAccount
+type CreditTransfer struct {
+	InstructionID   string
+	EndToEndID      string
+	Amount          int64
+	Currency        string
+	CreditorAccount string
+}
+
+// NumberOfTransfers counts the credit transfers of all the payments
+func (in Initiation) NumberOfTransfers() int {
+	n := 0
+	for _, payment := range in.Payments {
+		n += len(payment.Transfers)
+	}
+	return n
+}
+
+type pain001Document struct {
+	XMLName    xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	Initiation struct {
+		GroupHeader struct {
+			MessageID         string `xml:"MsgId"`
+			NumberOfTransfers string `xml:"NbOfTxs"`
+			ControlSum        string `xml:"CtrlSum"`
+		} `xml:"GrpHdr"`
+		Payments []struct {
+			ID                string         `xml:"PmtInfId"`
+			Method            string         `xml:"PmtMtd"`
+			NumberOfTransfers string         `xml:"NbOfTxs"`
+			ControlSum        string         `xml:"CtrlSum"`
+			DebtorAccount     pain001Account `xml:"DbtrAcct"`
+			Transfers         []struct {
+				InstructionID   string         `xml:"PmtId>InstrId"`
+				EndToEndID      string         `xml:"PmtId>EndToEndId"`
+				Amount          pain001Amount  `xml:"Amt>InstdAmt"`
+				CreditorAccount pain001Account `xml:"CdtrAcct"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Account struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+// number is the account number, the bank's own accounts are the Othr ones
+func (a pain001Account) number() string {
+	if a.Other != "" {
+		return a.Other
+	}
+	return a.IBAN
+}
+
+type pain001Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// Parse reads a pain.001 and checks it can be booked as a whole: the identifications are there,
+// the amounts are valid in the decimals of their currency in the registry and the number of transfers
+// and control sums add up. It doesn't check the accounts, that's done transfer by transfer by the Importer.
+func Parse(r io.Reader, currencies *util.CurrencyRegistry) (Initiation, error) {
+	var doc pain001Document
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return Initiation{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	in, err := doc.initiation(currencies)
+	if err != nil {
+		return Initiation{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	return in, nil
+}
+
+func (doc pain001Document) initiation(currencies *util.CurrencyRegistry) (Initiation, error) {
+	header := doc.Initiation.GroupHeader
+	if err := checkID("MsgId", header.MessageID); err != nil {
+		return Initiation{}, err
+	}
+
+	in := Initiation{MessageID: header.MessageID}
+	var sum controlSum
+	endToEndIDs := make(map[string]bool)
+
+	for _, p := range doc.Initiation.Payments {
+		if err := checkID("PmtInfId", p.ID); err != nil {
+			return Initiation{}, err
+		}
+		if p.Method != "TRF" {
+			return Initiation{}, fmt.Errorf("payment %s: PmtMtd must be TRF, got %q", p.ID, p.Method)
+		}
+
+		payment := Payment{ID: p.ID, DebtorAccount: p.DebtorAccount.number()}
+		if payment.DebtorAccount == "" {
+			return Initiation{}, fmt.Errorf("payment %s: missing DbtrAcct", p.ID)
+		}
+
+		var paymentSum controlSum
+		for _, t := range p.Transfers {
+			if err := checkID("EndToEndId", t.EndToEndID); err != nil {
+				return Initiation{}, fmt.Errorf("payment %s: %v", p.ID, err)
+			}
+			// the end to end ids make the transfers idempotent
+			if endToEndIDs[t.EndToEndID] {
+				return Initiation{}, fmt.Errorf("duplicate EndToEndId %s", t.EndToEndID)
+			}
+			endToEndIDs[t.EndToEndID] = true
+
+			if t.Amount.Currency == "" {
+				return Initiation{}, fmt.Errorf("transfer %s: missing the Ccy of InstdAmt", t.EndToEndID)
+			}
+			// the decimals of the amount depend on its currency
+			currency, ok := currencies.Get(t.Amount.Currency)
+			if !ok {
+				return Initiation{}, fmt.Errorf("transfer %s: unknown currency %s", t.EndToEndID, t.Amount.Currency)
+			}
+			amount, err := parseAmount(t.Amount.Value, currency)
+			if err != nil || amount == 0 {
+				return Initiation{}, fmt.Errorf("transfer %s: invalid InstdAmt %q", t.EndToEndID, t.Amount.Value)
+			}
+
+			transfer := CreditTransfer{
+				InstructionID:   t.InstructionID,
+				EndToEndID:      t.EndToEndID,
+				Amount:          amount,
+				Currency:        t.Amount.Currency,
+				CreditorAccount: t.CreditorAccount.number(),
+			}
+			if transfer.CreditorAccount == "" {
+				return Initiation{}, fmt.Errorf("transfer %s: missing CdtrAcct", t.EndToEndID)
+			}
+
+			payment.Transfers = append(payment.Transfers, transfer)
+			if err = paymentSum.add(amount, currency.Exponent); err != nil {
+				return Initiation{}, fmt.Errorf("payment %s: the amounts add up to more than the largest amount", p.ID)
+			}
+		}
+
+		if len(payment.Transfers) == 0 {
+			return Initiation{}, fmt.Errorf("payment %s has no CdtTrfTxInf", p.ID)
+		}
+		if err := checkTotals("payment "+p.ID, p.NumberOfTransfers, p.ControlSum, len(payment.Transfers), paymentSum); err != nil {
+			return Initiation{}, err
+		}
+
+		in.Payments = append(in.Payments, payment)
+		if err := sum.add(paymentSum.amount, paymentSum.exponent); err != nil {
+			return Initiation{}, errors.New("the amounts of the file add up to more than the largest amount")
+		}
+	}
+
+	if len(in.Payments) == 0 {
+		return Initiation{}, errors.New("the file has no PmtInf")
+	}
+	if header.NumberOfTransfers == "" {
+		return Initiation{}, errors.New("missing NbOfTxs")
+	}
+	if err := checkTotals("group", header.NumberOfTransfers, header.ControlSum, in.NumberOfTransfers(), sum); err != nil {
+		return Initiation{}, err
+	}
+
+	return in, nil
+}
+
+func checkID(name string, id string) error {
+	if id == "" {
+		return fmt.Errorf("missing %s", name)
+	}
+	if len(id) > maxTextLength {
+		return fmt.Errorf("%s %s is longer than %d characters", name, id, maxTextLength)
+	}
+	return nil
+}
+
+// checkTotals checks the NbOfTxs and CtrlSum declared by the file, when they are there,
+// are the ones of the transfers
+func checkTotals(what string, declaredNumber string, declaredSum string, number int, sum controlSum) error {
+	if declaredNumber != "" && declaredNumber != strconv.Itoa(number) {
+		return fmt.Errorf("%s: NbOfTxs is %s, there are %d transfers", what, declaredNumber, number)
+	}
+
+	if declaredSum != "" {
+		if strings.HasPrefix(strings.TrimSpace(declaredSum), "-") {
+			return fmt.Errorf("%s: invalid CtrlSum %q", what, declaredSum)
+		}
+		// the CtrlSum can have more decimals than the currencies, like 1000.00 for amounts without them
+		exponent := sum.exponent
+		if _, fraction, _ := strings.Cut(strings.TrimSpace(declaredSum), "."); int32(len(fraction)) > exponent {
+			exponent = int32(len(fraction))
+		}
+		controlSum, err := util.ParseAmount(declaredSum, exponent)
+		if err != nil {
+			return fmt.Errorf("%s: invalid CtrlSum %q", what, declaredSum)
+		}
+		total, err := scaleAmount(sum.amount, exponent-sum.exponent)
+		if err != nil || controlSum != total {
+			return fmt.Errorf("%s: CtrlSum is %s, the transfers add up to %s", what, declaredSum, util.FormatAmount(sum.amount, sum.exponent))
+		}
+	}
+
+	return nil
+}
+
+// parseAmount reads a decimal amount in minor units of the currency, 123.4 is 12340 in one with 2 decimals.
+// The amounts of a file are never negative nor over util.MaxAmount.
+func parseAmount(s string, currency util.Currency) (int64, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "-") {
+		return 0, fmt.Errorf("%w: %q is negative", util.ErrInvalidAmount, s)
+	}
+
+	money, err := util.ParseMoney(s, currency)
+	if err != nil {
+		return 0, err
+	}
+	if money.Amount > util.MaxAmount {
+		return 0, fmt.Errorf("%w: %q is over %s", util.ErrAmountOverflow, s, util.NewMoney(util.MaxAmount, currency))
+	}
+
+	return money.Amount, nil
+}
+
+// controlSum adds up amounts of currencies with different decimals, the way CtrlSum adds up the
+// decimals of the file. It keeps the amount in minor units of the largest exponent added so far.
+type controlSum struct {
+	amount   int64
+	exponent int32
+}
+
+// add sums an amount in minor units of a currency with the exponent
+func (c *controlSum) add(amount int64, exponent int32) error {
+	var err error
+	if exponent > c.exponent {
+		if c.amount, err = scaleAmount(c.amount, exponent-c.exponent); err != nil {
+			return err
+		}
+		c.exponent = exponent
+	}
+	if amount, err = scaleAmount(amount, c.exponent-exponent); err != nil {
+		return err
+	}
+
+	c.amount, err = util.AddAmounts(c.amount, amount)
+	return err
+}
+
+// scaleAmount adds decimals to an amount in minor units, 12345 with 1 more decimal is 123450
+func scaleAmount(amount int64, decimals int32) (int64, error) {
+	var err error
+	for i := int32(0); i < decimals && err == nil; i++ {
+		amount, err = util.MulAmounts(amount, 10)
+	}
+	return amount, err
+}
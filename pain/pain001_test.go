@@ -0,0 +1,153 @@
+package pain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// testCurrencies are the default currencies and two without 2 decimals
+var testCurrencies = util.NewCurrencyRegistry([]util.Currency{
+	{Code: util.ARS, Exponent: 2, Enabled: true},
+	{Code: util.EUR, Exponent: 2, Enabled: true},
+	{Code: util.USD, Exponent: 2, Enabled: true},
+	{Code: "JPY", Exponent: 0, Enabled: true},
+	{Code: "KWD", Exponent: 3, Enabled: true},
+})
+
+// testFile is a pain.001 with a payment of two transfers from account 1, to the accounts 2 and 3
+const testFile = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>PAYROLL-2023-03</MsgId>
+      <CreDtTm>2023-03-31T09:00:00</CreDtTm>
+      <NbOfTxs>%s</NbOfTxs>
+      <CtrlSum>%s</CtrlSum>
+      <InitgPty><Nm>ACME</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PAYROLL-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <ReqdExctnDt>2023-03-31</ReqdExctnDt>
+      <Dbtr><Nm>ACME</Nm></Dbtr>
+      <DbtrAcct><Id><Othr><Id>1</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">%s</InstdAmt></Amt>
+        <Cdtr><Nm>Alice</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>%s</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">0.5</InstdAmt></Amt>
+        <Cdtr><Nm>Bob</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>3</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func testFileWith(numberOfTransfers, controlSum, amount, endToEndID string) string {
+	return fmt.Sprintf(testFile, numberOfTransfers, controlSum, amount, endToEndID)
+}
+
+// testFileIn is the testFile with the currencies of the two transfers
+func testFileIn(controlSum, currency1, amount1, currency2, amount2 string) string {
+	file := testFileWith("2", controlSum, amount1, "E2E-2")
+	file = strings.Replace(file, `Ccy="USD"`, fmt.Sprintf("Ccy=%q", currency1), 1)
+	file = strings.Replace(file, `Ccy="USD">0.5`, fmt.Sprintf("Ccy=%q>%s", currency2, amount2), 1)
+	return file
+}
+
+func TestParse(t *testing.T) {
+	in, err := Parse(strings.NewReader(testFileWith("2", "1234.50", "1234", "E2E-2")), testCurrencies)
+	require.NoError(t, err)
+
+	require.Equal(t, Initiation{
+		MessageID: "PAYROLL-2023-03",
+		Payments: []Payment{{
+			ID:            "PAYROLL-1",
+			DebtorAccount: "1",
+			Transfers: []CreditTransfer{
+				{InstructionID: "I-1", EndToEndID: "E2E-1", Amount: 123400, Currency: "USD", CreditorAccount: "2"},
+				{EndToEndID: "E2E-2", Amount: 50, Currency: "USD", CreditorAccount: "3"},
+			},
+		}},
+	}, in)
+	require.Equal(t, 2, in.NumberOfTransfers())
+}
+
+func TestParseCurrencyExponent(t *testing.T) {
+	in, err := Parse(strings.NewReader(testFileIn("1500.125", "JPY", "1500", "KWD", "0.125")), testCurrencies)
+	require.NoError(t, err)
+
+	transfers := in.Payments[0].Transfers
+	require.Equal(t, int64(1500), transfers[0].Amount)
+	require.Equal(t, int64(125), transfers[1].Amount)
+
+	// trailing zeros past the decimals of the currencies
+	_, err = Parse(strings.NewReader(testFileIn("1501.00", "JPY", "1500", "JPY", "1")), testCurrencies)
+	require.NoError(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+	}{
+		{name: "NotXML", file: "MsgId,Amount\n1,100"},
+		{name: "OtherMessage", file: strings.Replace(testFileWith("2", "1234.50", "1234", "E2E-2"), "pain.001.001.03", "pain.008.001.02", 1)},
+		{name: "NumberOfTransfers", file: testFileWith("3", "1234.50", "1234", "E2E-2")},
+		{name: "ControlSum", file: testFileWith("2", "1234.00", "1234", "E2E-2")},
+		{name: "Amount", file: testFileWith("2", "1234.50", "12,34", "E2E-2")},
+		{name: "AmountDecimals", file: testFileWith("2", "1234.50", "1234.001", "E2E-2")},
+		{name: "ZeroAmount", file: testFileWith("2", "0.50", "0.00", "E2E-2")},
+		{name: "OverMaxAmount", file: testFileWith("2", "", "1000000000000.01", "E2E-2")},
+		{name: "UnknownCurrency", file: testFileIn("", "XXX", "1234", "USD", "0.5")},
+		{name: "DecimalsOfCurrency", file: testFileIn("", "JPY", "1234.5", "USD", "0.5")},
+		{name: "ControlSumOfCurrencies", file: testFileIn("1234.50", "JPY", "1234", "KWD", "0.005")},
+		{name: "MissingEndToEndID", file: testFileWith("2", "1234.50", "1234", "")},
+		{name: "DuplicateEndToEndID", file: testFileWith("2", "1234.50", "1234", "E2E-1")},
+		{name: "LongEndToEndID", file: testFileWith("2", "1234.50", "1234", strings.Repeat("E", 36))},
+		{name: "Method", file: strings.Replace(testFileWith("2", "1234.50", "1234", "E2E-2"), "<PmtMtd>TRF", "<PmtMtd>CHK", 1)},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.file), testCurrencies)
+			require.ErrorIs(t, err, ErrInvalidFile)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	usd, _ := testCurrencies.Get(util.USD)
+	for s, want := range map[string]int64{"0.01": 1, "1": 100, "1.5": 150, "123.45": 12345, " 7.00 ": 700, "1000000000000": util.MaxAmount} {
+		amount, err := parseAmount(s, usd)
+		require.NoError(t, err)
+		require.Equal(t, want, amount, s)
+	}
+
+	for _, s := range []string{"", "-1", "1.", ".5", "1.234", "1e3", "1,5", "1000000000000.01", "92233720368547758.08"} {
+		_, err := parseAmount(s, usd)
+		require.Error(t, err, s)
+	}
+
+	jpy, _ := testCurrencies.Get("JPY")
+	amount, err := parseAmount("1500", jpy)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), amount)
+	_, err = parseAmount("15.5", jpy)
+	require.Error(t, err)
+
+	kwd, _ := testCurrencies.Get("KWD")
+	amount, err = parseAmount("1.234", kwd)
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), amount)
+}
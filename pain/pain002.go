@@ -0,0 +1,175 @@
+package pain
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+const pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+
+// Statuses of the transfers, the payments and the whole file
+const (
+	// StatusAccepted is a transfer booked, ACSC is accepted and settled
+	StatusAccepted = "ACSC"
+	StatusRejected = "RJCT"
+	// StatusPartial is a payment or file with some of its transfers rejected
+	StatusPartial = "PART"
+)
+
+// Reasons a transfer is rejected for, from the ExternalStatusReason1Code list
+const (
+	ReasonIncorrectAccount   = "AC01"
+	ReasonClosedAccount      = "AC04"
+	ReasonBlockedAccount     = "AC06"
+	ReasonForbidden          = "AG01"
+	ReasonAmountNotAllowed   = "AM02"
+	ReasonCurrencyNotAllowed = "AM03"
+	ReasonInsufficientFunds  = "AM04"
+	ReasonDuplication        = "AM05"
+	// ReasonNarrative is any other reason, explained by the additional information
+	ReasonNarrative = "NARR"
+)
+
+// maxAdditionalInfoLength is the length of AddtlInf, Max105Text in the schema
+const maxAdditionalInfoLength = 105
+
+// StatusReport is the status of each transfer of an Initiation after importing it
+type StatusReport struct {
+	MessageID         string
+	CreatedAt         time.Time
+	OriginalMessageID string
+	NumberOfTransfers int
+	Status            string
+	Payments          []PaymentStatus
+}
+
+type PaymentStatus struct {
+	PaymentID string
+	Status    string
+	Transfers []TransferStatus
+}
+
+// TransferStatus has the id of the transfer when it was booked, and the reason otherwise
+type TransferStatus struct {
+	InstructionID  string
+	EndToEndID     string
+	Status         string
+	TransferID     int64
+	Reason         string
+	AdditionalInfo string
+}
+
+// groupStatus is ACSC when all the statuses are, RJCT when all are rejected and PART otherwise
+func groupStatus(statuses []string) string {
+	accepted := 0
+	for _, status := range statuses {
+		if status == StatusPartial {
+			return StatusPartial
+		}
+		if status == StatusAccepted {
+			accepted++
+		}
+	}
+
+	switch accepted {
+	case len(statuses):
+		return StatusAccepted
+	case 0:
+		return StatusRejected
+	}
+	return StatusPartial
+}
+
+type pain002Document struct {
+	XMLName xml.Name       `xml:"Document"`
+	XMLNS   string         `xml:"xmlns,attr"`
+	Report  pain002Content `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002Content struct {
+	GroupHeader struct {
+		MessageID string `xml:"MsgId"`
+		CreatedAt string `xml:"CreDtTm"`
+	} `xml:"GrpHdr"`
+	Original struct {
+		MessageID         string `xml:"OrgnlMsgId"`
+		MessageName       string `xml:"OrgnlMsgNmId"`
+		NumberOfTransfers string `xml:"OrgnlNbOfTxs"`
+		Status            string `xml:"GrpSts"`
+	} `xml:"OrgnlGrpInfAndSts"`
+	Payments []pain002Payment `xml:"OrgnlPmtInfAndSts"`
+}
+
+type pain002Payment struct {
+	PaymentID string            `xml:"OrgnlPmtInfId"`
+	Status    string            `xml:"PmtInfSts"`
+	Transfers []pain002Transfer `xml:"TxInfAndSts"`
+}
+
+// pain002Transfer has its elements in the order of the schema
+type pain002Transfer struct {
+	InstructionID string         `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string         `xml:"OrgnlEndToEndId"`
+	Status        string         `xml:"TxSts"`
+	Reason        *pain002Reason `xml:"StsRsnInf,omitempty"`
+	ServicerRef   string         `xml:"AcctSvcrRef,omitempty"`
+}
+
+type pain002Reason struct {
+	Code           string `xml:"Rsn>Cd"`
+	AdditionalInfo string `xml:"AddtlInf,omitempty"`
+}
+
+// WriteTo writes the report as a pain.002, the transfers booked have their id as AcctSvcrRef
+func (r StatusReport) WriteTo(w io.Writer) (int64, error) {
+	doc := pain002Document{XMLNS: pain002Namespace}
+	content := &doc.Report
+	content.GroupHeader.MessageID = r.MessageID
+	content.GroupHeader.CreatedAt = r.CreatedAt.UTC().Format("2006-01-02T15:04:05Z")
+	content.Original.MessageID = r.OriginalMessageID
+	content.Original.MessageName = Pain001Version
+	content.Original.NumberOfTransfers = strconv.Itoa(r.NumberOfTransfers)
+	content.Original.Status = r.Status
+
+	for _, payment := range r.Payments {
+		p := pain002Payment{PaymentID: payment.PaymentID, Status: payment.Status}
+		for _, transfer := range payment.Transfers {
+			t := pain002Transfer{
+				InstructionID: transfer.InstructionID,
+				EndToEndID:    transfer.EndToEndID,
+				Status:        transfer.Status,
+			}
+			if transfer.TransferID != 0 {
+				t.ServicerRef = strconv.FormatInt(transfer.TransferID, 10)
+			}
+			if transfer.Reason != "" {
+				t.Reason = &pain002Reason{Code: transfer.Reason, AdditionalInfo: truncate(transfer.AdditionalInfo, maxAdditionalInfoLength)}
+			}
+			p.Transfers = append(p.Transfers, t)
+		}
+		content.Payments = append(content.Payments, p)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(append(out, '\n'))
+	return int64(n + m), err
+}
+
+// truncate cuts s to n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
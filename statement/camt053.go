@@ -0,0 +1,172 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053TimeLayout is the ISODateTime of the messages
+const camt053TimeLayout = "2006-01-02T15:04:05Z"
+
+// camt053Writer writes a camt.053.001.02 bank to customer statement. The schema puts the balances
+// before the entries, that's why StatementTx passes the closing balance to Begin.
+type camt053Writer struct {
+	x      *xmlWriter
+	header Header
+}
+
+func newCamt053Writer(w io.Writer, header Header) db.StatementWriter {
+	return &camt053Writer{
+		x:      newXMLWriter(w),
+		header: header,
+	}
+}
+
+func (c *camt053Writer) Begin(opening int64, closing int64) error {
+	x := c.x
+	id := c.statementID()
+	createdAt := camt053Time(c.header.CreatedAt)
+
+	x.procInst("xml", `version="1.0" encoding="UTF-8"`)
+	x.start(el("Document"), xml.Attr{Name: el("xmlns"), Value: camt053Namespace})
+	x.start(el("BkToCstmrStmt"))
+
+	x.start(el("GrpHdr"))
+	x.leaf("MsgId", id)
+	x.leaf("CreDtTm", createdAt)
+	x.end()
+
+	x.start(el("Stmt"))
+	x.leaf("Id", id)
+	x.leaf("CreDtTm", createdAt)
+	x.start(el("FrToDt"))
+	x.leaf("FrDtTm", camt053Time(c.header.From))
+	x.leaf("ToDtTm", camt053Time(c.header.To))
+	x.end()
+
+	x.start(el("Acct"))
+	c.account(c.header.Account.ID)
+	x.leaf("Ccy", c.header.Account.Currency)
+	x.start(el("Ownr"))
+	x.leaf("Nm", c.header.Account.Owner)
+	x.end()
+	x.end()
+
+	c.balance("OPBD", opening, c.header.From)
+	c.balance("CLBD", closing, c.header.To)
+
+	return x.err
+}
+
+func (c *camt053Writer) Entry(entry db.ListStatementEntriesRow) error {
+	x := c.x
+	id := strconv.FormatInt(entry.ID, 10)
+	bookedAt := camt053Time(entry.CreatedAt.Time)
+
+	x.start(el("Ntry"))
+	x.leaf("NtryRef", id)
+	c.amount(entry.Amount)
+	x.leaf("CdtDbtInd", creditDebit(entry.Amount))
+	x.leaf("Sts", "BOOK")
+	x.start(el("BookgDt"))
+	x.leaf("DtTm", bookedAt)
+	x.end()
+	x.start(el("ValDt"))
+	x.leaf("DtTm", bookedAt)
+	x.end()
+	x.leaf("AcctSvcrRef", id)
+	x.start(el("BkTxCd"))
+	x.start(el("Prtry"))
+	x.leaf("Cd", entryKind(entry))
+	x.end()
+	x.end()
+
+	if entry.TransferID.Valid {
+		x.start(el("NtryDtls"))
+		x.start(el("TxDtls"))
+		x.start(el("Refs"))
+		x.leaf("TxId", strconv.FormatInt(entry.TransferID.Int64, 10))
+		x.end()
+		if entry.CounterpartyAccountID != 0 {
+			// the counterparty pays the credits and is paid by the debits
+			party := "CdtrAcct"
+			if entry.Amount > 0 {
+				party = "DbtrAcct"
+			}
+			x.start(el("RltdPties"))
+			x.start(el(party))
+			c.account(entry.CounterpartyAccountID)
+			x.end()
+			x.end()
+		}
+		x.end()
+		x.end()
+	}
+
+	x.leaf("AddtlNtryInf", description(entry))
+	x.end()
+
+	return x.err
+}
+
+func (c *camt053Writer) End() error {
+	x := c.x
+	for len(x.open) > 0 {
+		x.end()
+	}
+
+	return x.flush()
+}
+
+func (c *camt053Writer) statementID() string {
+	return fmt.Sprintf("STMT-%d-%s", c.header.Account.ID, c.header.CreatedAt.UTC().Format("20060102150405"))
+}
+
+// account writes the Id of an account of the bank
+func (c *camt053Writer) account(id int64) {
+	x := c.x
+	x.start(el("Id"))
+	x.start(el("Othr"))
+	x.leaf("Id", strconv.FormatInt(id, 10))
+	x.end()
+	x.end()
+}
+
+func (c *camt053Writer) amount(amount int64) {
+	c.x.leaf("Amt", c.header.formatAmount(abs(amount)), xml.Attr{Name: el("Ccy"), Value: c.header.Account.Currency})
+}
+
+func (c *camt053Writer) balance(code string, balance int64, at time.Time) {
+	x := c.x
+	x.start(el("Bal"))
+	x.start(el("Tp"))
+	x.start(el("CdOrPrtry"))
+	x.leaf("Cd", code)
+	x.end()
+	x.end()
+	c.amount(balance)
+	x.leaf("CdtDbtInd", creditDebit(balance))
+	x.start(el("Dt"))
+	x.leaf("DtTm", camt053Time(at))
+	x.end()
+	x.end()
+}
+
+// creditDebit is the CdtDbtInd of an amount, zero balances are credits
+func creditDebit(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func camt053Time(t time.Time) string {
+	return t.UTC().Format(camt053TimeLayout)
+}
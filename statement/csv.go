@@ -0,0 +1,82 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+var csvColumns = []string{
+	"type", "date", "entry_id", "transfer_id", "counterparty_account_id", "description", "amount", "balance", "currency",
+}
+
+// csvWriter writes a row per entry with the balance after it, between a row with the opening
+// balance and one with the closing balance
+type csvWriter struct {
+	w       *csv.Writer
+	header  Header
+	balance int64
+	closing int64
+}
+
+func newCSVWriter(w io.Writer, header Header) db.StatementWriter {
+	return &csvWriter{
+		w:      csv.NewWriter(w),
+		header: header,
+	}
+}
+
+func (c *csvWriter) Begin(opening int64, closing int64) error {
+	c.balance = opening
+	c.closing = closing
+
+	err := c.w.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+
+	return c.balanceRow("opening", c.header.From, "Opening balance", opening)
+}
+
+func (c *csvWriter) Entry(entry db.ListStatementEntriesRow) error {
+	c.balance += entry.Amount
+
+	var transferID, counterparty string
+	if entry.TransferID.Valid {
+		transferID = strconv.FormatInt(entry.TransferID.Int64, 10)
+	}
+	if entry.CounterpartyAccountID != 0 {
+		counterparty = strconv.FormatInt(entry.CounterpartyAccountID, 10)
+	}
+
+	return c.w.Write([]string{
+		entryKind(entry),
+		entry.CreatedAt.Time.UTC().Format(time.RFC3339),
+		strconv.FormatInt(entry.ID, 10),
+		transferID,
+		counterparty,
+		description(entry),
+		c.header.formatAmount(entry.Amount),
+		c.header.formatAmount(c.balance),
+		c.header.Account.Currency,
+	})
+}
+
+func (c *csvWriter) End() error {
+	err := c.balanceRow("closing", c.header.To, "Closing balance", c.closing)
+	if err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) balanceRow(kind string, at time.Time, text string, balance int64) error {
+	return c.w.Write([]string{
+		kind, at.UTC().Format(time.RFC3339), "", "", "", text, "", c.header.formatAmount(balance), c.header.Account.Currency,
+	})
+}
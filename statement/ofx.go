@@ -0,0 +1,116 @@
+package statement
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+)
+
+// ofxBankID identifies the bank in the accounts of the statements
+const ofxBankID = "bank_demo"
+
+// ofxTimeLayout is the OFX datetime, always in GMT
+const ofxTimeLayout = "20060102150405.000[0:GMT]"
+
+// ofxTransactionTypes are the TRNTYPE of each kind of entry
+var ofxTransactionTypes = map[string]string{
+	kindDeposit:    "DEP",
+	kindWithdrawal: "CASH",
+	kindTransfer:   "XFER",
+	kindReversal:   "XFER",
+	kindFee:        "FEE",
+}
+
+// ofxWriter writes an OFX 2.2 bank statement. OFX has no element for the opening balance,
+// the closing one is the LEDGERBAL.
+type ofxWriter struct {
+	x       *xmlWriter
+	header  Header
+	closing int64
+}
+
+func newOFXWriter(w io.Writer, header Header) db.StatementWriter {
+	return &ofxWriter{
+		x:      newXMLWriter(w),
+		header: header,
+	}
+}
+
+func (o *ofxWriter) Begin(opening int64, closing int64) error {
+	o.closing = closing
+	x := o.x
+
+	x.procInst("xml", `version="1.0" encoding="UTF-8" standalone="no"`)
+	x.procInst("OFX", `OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)
+	x.start(el("OFX"))
+
+	x.start(el("SIGNONMSGSRSV1"))
+	x.start(el("SONRS"))
+	o.status()
+	x.leaf("DTSERVER", ofxTime(o.header.CreatedAt))
+	x.leaf("LANGUAGE", "ENG")
+	x.end()
+	x.end()
+
+	x.start(el("BANKMSGSRSV1"))
+	x.start(el("STMTTRNRS"))
+	x.leaf("TRNUID", "0")
+	o.status()
+	x.start(el("STMTRS"))
+	x.leaf("CURDEF", o.header.Account.Currency)
+	x.start(el("BANKACCTFROM"))
+	x.leaf("BANKID", ofxBankID)
+	x.leaf("ACCTID", strconv.FormatInt(o.header.Account.ID, 10))
+	x.leaf("ACCTTYPE", "CHECKING")
+	x.end()
+
+	x.start(el("BANKTRANLIST"))
+	x.leaf("DTSTART", ofxTime(o.header.From))
+	x.leaf("DTEND", ofxTime(o.header.To))
+
+	return x.err
+}
+
+func (o *ofxWriter) Entry(entry db.ListStatementEntriesRow) error {
+	x := o.x
+
+	x.start(el("STMTTRN"))
+	x.leaf("TRNTYPE", ofxTransactionTypes[entryKind(entry)])
+	x.leaf("DTPOSTED", ofxTime(entry.CreatedAt.Time))
+	x.leaf("TRNAMT", o.header.formatAmount(entry.Amount))
+	x.leaf("FITID", strconv.FormatInt(entry.ID, 10))
+	x.leaf("NAME", description(entry))
+	x.end()
+
+	return x.err
+}
+
+func (o *ofxWriter) End() error {
+	x := o.x
+
+	x.end() // BANKTRANLIST
+	x.start(el("LEDGERBAL"))
+	x.leaf("BALAMT", o.header.formatAmount(o.closing))
+	x.leaf("DTASOF", ofxTime(o.header.To))
+	x.end()
+
+	for len(x.open) > 0 {
+		x.end()
+	}
+
+	return x.flush()
+}
+
+// status is the STATUS of a successful response
+func (o *ofxWriter) status() {
+	o.x.start(el("STATUS"))
+	o.x.leaf("CODE", "0")
+	o.x.leaf("SEVERITY", "INFO")
+	o.x.end()
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeLayout)
+}
@@ -0,0 +1,126 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+)
+
+// Formats a statement can be rendered in
+const (
+	CSV     = "csv"
+	OFX     = "ofx"
+	Camt053 = "camt053"
+)
+
+// ErrUnknownFormat is returned for a format that isn't one of the above
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Header is what a statement says about itself before the entries
+type Header struct {
+	Account db.Accounts
+	// Currency is the one of the account, the amounts are written with the decimals of its exponent
+	Currency util.Currency
+	// From and To are the period of the statement, [From, To)
+	From      time.Time
+	To        time.Time
+	CreatedAt time.Time
+}
+
+type format struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer, header Header) db.StatementWriter
+}
+
+var formats = map[string]format{
+	CSV:     {contentType: "text/csv", extension: "csv", newWriter: newCSVWriter},
+	OFX:     {contentType: "application/x-ofx", extension: "ofx", newWriter: newOFXWriter},
+	Camt053: {contentType: "application/xml", extension: "xml", newWriter: newCamt053Writer},
+}
+
+// NewWriter creates a writer that renders the statement in the format to w
+func NewWriter(name string, w io.Writer, header Header) (db.StatementWriter, error) {
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+
+	return f.newWriter(w, header), nil
+}
+
+// ContentType is the media type of the statements in the format
+func ContentType(name string) string {
+	return formats[name].contentType
+}
+
+// FileName is the name of the file of the statement in the format
+func FileName(name string, header Header) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s", header.Account.ID,
+		header.From.UTC().Format("20060102"), header.To.UTC().Format("20060102"), formats[name].extension)
+}
+
+// Kinds of entries, by what booked them
+const (
+	kindDeposit    = "deposit"
+	kindWithdrawal = "withdrawal"
+	kindTransfer   = "transfer"
+	kindReversal   = "reversal"
+	kindFee        = "fee"
+)
+
+// entryKind tells what booked the entry. Entries without a transfer are cash operations and the ones
+// of a transfer marked as fee are its fee, even when it's equal to the amount, or its refund on a reversal.
+func entryKind(entry db.ListStatementEntriesRow) string {
+	switch {
+	case !entry.TransferID.Valid && entry.Amount > 0:
+		return kindDeposit
+	case !entry.TransferID.Valid:
+		return kindWithdrawal
+	case entry.Fee:
+		return kindFee
+	case entry.ReversedTransferID.Valid:
+		return kindReversal
+	}
+
+	return kindTransfer
+}
+
+// description is the text of the entry for a person
+func description(entry db.ListStatementEntriesRow) string {
+	switch entryKind(entry) {
+	case kindDeposit:
+		return "Deposit"
+	case kindWithdrawal:
+		return "Withdrawal"
+	case kindFee:
+		if entry.ReversedTransferID.Valid {
+			return fmt.Sprintf("Fee refund of transfer %d", entry.ReversedTransferID.Int64)
+		}
+		return fmt.Sprintf("Fee of transfer %d", entry.TransferID.Int64)
+	case kindReversal:
+		return fmt.Sprintf("Reversal of transfer %d", entry.ReversedTransferID.Int64)
+	}
+
+	if entry.Amount > 0 {
+		return fmt.Sprintf("Transfer from account %d", entry.CounterpartyAccountID)
+	}
+	return fmt.Sprintf("Transfer to account %d", entry.CounterpartyAccountID)
+}
+
+// formatAmount writes an amount in minor units as a decimal, 12345 is 123.45 in a currency with 2 decimals
+func (h Header) formatAmount(amount int64) string {
+	return util.NewMoney(amount, h.Currency).Decimal()
+}
+
+// abs is the absolute value of an amount, the XML formats carry the sign apart
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,206 @@
+package statement
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+var testHeader = Header{
+	Account:   db.Accounts{ID: 42, Owner: "alice", Currency: "EUR"},
+	Currency:  util.Currency{Code: "EUR", NumericCode: "978", Exponent: 2, Enabled: true},
+	From:      time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+	To:        time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC),
+	CreatedAt: time.Date(2023, time.April, 2, 8, 30, 0, 0, time.UTC),
+}
+
+// testEntries are a deposit, a transfer out with its fee and a transfer in, 1000 + 500 - 250 - 10 + 120
+var testEntries = []db.ListStatementEntriesRow{
+	{ID: 1, Amount: 500, CreatedAt: testTime(1)},
+	{ID: 2, Amount: -250, CreatedAt: testTime(2), TransferID: sql.NullInt64{Int64: 5, Valid: true}, CounterpartyAccountID: 9},
+	{ID: 4, Amount: -10, CreatedAt: testTime(2), TransferID: sql.NullInt64{Int64: 5, Valid: true}, Fee: true},
+	{ID: 6, Amount: 120, CreatedAt: testTime(3), TransferID: sql.NullInt64{Int64: 6, Valid: true}, CounterpartyAccountID: 9},
+}
+
+func testTime(day int) sql.NullTime {
+	return sql.NullTime{Time: time.Date(2023, time.March, day, 10, 0, 0, 0, time.UTC), Valid: true}
+}
+
+func writeStatement(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, testHeader)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Begin(1000, 1360))
+	for _, entry := range testEntries {
+		require.NoError(t, w.Entry(entry))
+	}
+	require.NoError(t, w.End())
+
+	return buf.Bytes()
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewWriter("pdf", &bytes.Buffer{}, testHeader)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestCSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(writeStatement(t, CSV))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(testEntries)+3)
+
+	require.Equal(t, csvColumns, rows[0])
+	require.Equal(t, []string{"opening", "2023-03-01T00:00:00Z", "", "", "", "Opening balance", "", "10.00", "EUR"}, rows[1])
+	require.Equal(t, []string{"deposit", "2023-03-01T10:00:00Z", "1", "", "", "Deposit", "5.00", "15.00", "EUR"}, rows[2])
+	require.Equal(t, []string{"transfer", "2023-03-02T10:00:00Z", "2", "5", "9", "Transfer to account 9", "-2.50", "12.50", "EUR"}, rows[3])
+	require.Equal(t, []string{"fee", "2023-03-02T10:00:00Z", "4", "5", "", "Fee of transfer 5", "-0.10", "12.40", "EUR"}, rows[4])
+	require.Equal(t, []string{"transfer", "2023-03-03T10:00:00Z", "6", "6", "9", "Transfer from account 9", "1.20", "13.60", "EUR"}, rows[5])
+	require.Equal(t, []string{"closing", "2023-04-01T00:00:00Z", "", "", "", "Closing balance", "", "13.60", "EUR"}, rows[6])
+}
+
+func TestOFX(t *testing.T) {
+	var doc struct {
+		XMLName xml.Name `xml:"OFX"`
+		Stmt    struct {
+			Currency string `xml:"CURDEF"`
+			Account  string `xml:"BANKACCTFROM>ACCTID"`
+			Start    string `xml:"BANKTRANLIST>DTSTART"`
+			Entries  []struct {
+				Type   string `xml:"TRNTYPE"`
+				Posted string `xml:"DTPOSTED"`
+				Amount string `xml:"TRNAMT"`
+				ID     string `xml:"FITID"`
+			} `xml:"BANKTRANLIST>STMTTRN"`
+			Closing string `xml:"LEDGERBAL>BALAMT"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+	}
+	require.NoError(t, xml.Unmarshal(writeStatement(t, OFX), &doc))
+
+	require.Equal(t, "EUR", doc.Stmt.Currency)
+	require.Equal(t, "42", doc.Stmt.Account)
+	require.Equal(t, "20230301000000.000[0:GMT]", doc.Stmt.Start)
+	require.Equal(t, "13.60", doc.Stmt.Closing)
+
+	require.Len(t, doc.Stmt.Entries, len(testEntries))
+	types := []string{"DEP", "XFER", "FEE", "XFER"}
+	for i, entry := range doc.Stmt.Entries {
+		require.Equal(t, types[i], entry.Type)
+		require.Equal(t, testHeader.formatAmount(testEntries[i].Amount), entry.Amount)
+	}
+	require.Equal(t, "20230302100000.000[0:GMT]", doc.Stmt.Entries[1].Posted)
+	require.Equal(t, "2", doc.Stmt.Entries[1].ID)
+}
+
+func TestCamt053(t *testing.T) {
+	out := writeStatement(t, Camt053)
+	require.NotContains(t, string(out), `xmlns=""`)
+
+	type amount struct {
+		Currency string `xml:"Ccy,attr"`
+		Value    string `xml:",chardata"`
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+		Stmt    struct {
+			Account  string `xml:"Acct>Id>Othr>Id"`
+			Currency string `xml:"Acct>Ccy"`
+			Owner    string `xml:"Acct>Ownr>Nm"`
+			Balances []struct {
+				Code   string `xml:"Tp>CdOrPrtry>Cd"`
+				Amount amount `xml:"Amt"`
+				Sign   string `xml:"CdtDbtInd"`
+			} `xml:"Bal"`
+			Entries []struct {
+				Amount      amount `xml:"Amt"`
+				Sign        string `xml:"CdtDbtInd"`
+				Code        string `xml:"BkTxCd>Prtry>Cd"`
+				TransferID  string `xml:"NtryDtls>TxDtls>Refs>TxId"`
+				Debtor      string `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct>Id>Othr>Id"`
+				Creditor    string `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct>Id>Othr>Id"`
+				Information string `xml:"AddtlNtryInf"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	require.NoError(t, xml.Unmarshal(out, &doc))
+
+	require.Equal(t, "42", doc.Stmt.Account)
+	require.Equal(t, "EUR", doc.Stmt.Currency)
+	require.Equal(t, "alice", doc.Stmt.Owner)
+
+	require.Len(t, doc.Stmt.Balances, 2)
+	require.Equal(t, "OPBD", doc.Stmt.Balances[0].Code)
+	require.Equal(t, amount{Currency: "EUR", Value: "10.00"}, doc.Stmt.Balances[0].Amount)
+	require.Equal(t, "CLBD", doc.Stmt.Balances[1].Code)
+	require.Equal(t, amount{Currency: "EUR", Value: "13.60"}, doc.Stmt.Balances[1].Amount)
+
+	require.Len(t, doc.Stmt.Entries, len(testEntries))
+	deposit, sent, fee, received := doc.Stmt.Entries[0], doc.Stmt.Entries[1], doc.Stmt.Entries[2], doc.Stmt.Entries[3]
+
+	require.Equal(t, "CRDT", deposit.Sign)
+	require.Equal(t, "deposit", deposit.Code)
+	require.Empty(t, deposit.TransferID)
+
+	require.Equal(t, "DBIT", sent.Sign)
+	require.Equal(t, "2.50", sent.Amount.Value)
+	require.Equal(t, "5", sent.TransferID)
+	require.Equal(t, "9", sent.Creditor)
+	require.Empty(t, sent.Debtor)
+
+	require.Equal(t, "fee", fee.Code)
+	require.Empty(t, fee.Creditor)
+
+	require.Equal(t, "CRDT", received.Sign)
+	require.Equal(t, "9", received.Debtor)
+	require.Equal(t, "Transfer from account 9", received.Information)
+}
+
+func TestEntryKind(t *testing.T) {
+	reversal := db.ListStatementEntriesRow{
+		Amount:                250,
+		TransferID:            sql.NullInt64{Int64: 8, Valid: true},
+		CounterpartyAccountID: 9,
+		ReversedTransferID:    sql.NullInt64{Int64: 5, Valid: true},
+	}
+	require.Equal(t, kindReversal, entryKind(reversal))
+	require.Equal(t, "Reversal of transfer 5", description(reversal))
+
+	require.Equal(t, kindWithdrawal, entryKind(db.ListStatementEntriesRow{Amount: -300}))
+
+	// a fee equal to the amount of its transfer is still the fee
+	transfer := db.ListStatementEntriesRow{Amount: -250, TransferID: sql.NullInt64{Int64: 5, Valid: true}, CounterpartyAccountID: 9}
+	fee := db.ListStatementEntriesRow{Amount: -250, TransferID: sql.NullInt64{Int64: 5, Valid: true}, Fee: true}
+	require.Equal(t, kindTransfer, entryKind(transfer))
+	require.Equal(t, "Transfer to account 9", description(transfer))
+	require.Equal(t, kindFee, entryKind(fee))
+	require.Equal(t, "Fee of transfer 5", description(fee))
+
+	// the fee refunded by a reversal
+	feeRefund := db.ListStatementEntriesRow{
+		Amount:             10,
+		TransferID:         sql.NullInt64{Int64: 8, Valid: true},
+		ReversedTransferID: sql.NullInt64{Int64: 5, Valid: true},
+		Fee:                true,
+	}
+	require.Equal(t, kindFee, entryKind(feeRefund))
+	require.Equal(t, "Fee refund of transfer 5", description(feeRefund))
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", testHeader.formatAmount(0))
+	require.Equal(t, "0.05", testHeader.formatAmount(5))
+	require.Equal(t, "123.45", testHeader.formatAmount(12345))
+	require.Equal(t, "-0.99", testHeader.formatAmount(-99))
+	require.Equal(t, "-10.00", testHeader.formatAmount(-1000))
+
+	// the decimals are the ones of the currency
+	jpy := Header{Currency: util.Currency{Code: "JPY", NumericCode: "392", Exponent: 0}}
+	require.Equal(t, "-1000", jpy.formatAmount(-1000))
+}
@@ -0,0 +1,63 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xmlWriter writes an XML document an element at a time. It keeps the first error,
+// the writers check it once per entry.
+type xmlWriter struct {
+	enc  *xml.Encoder
+	open []xml.Name
+	err  error
+}
+
+func newXMLWriter(w io.Writer) *xmlWriter {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return &xmlWriter{enc: enc}
+}
+
+func (x *xmlWriter) token(t xml.Token) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(t)
+	}
+}
+
+func (x *xmlWriter) procInst(target string, inst string) {
+	x.token(xml.ProcInst{Target: target, Inst: []byte(inst)})
+}
+
+// start opens an element, closed by the next call to end
+func (x *xmlWriter) start(name xml.Name, attrs ...xml.Attr) {
+	x.token(xml.StartElement{Name: name, Attr: attrs})
+	x.open = append(x.open, name)
+}
+
+func (x *xmlWriter) end() {
+	name := x.open[len(x.open)-1]
+	x.open = x.open[:len(x.open)-1]
+	x.token(xml.EndElement{Name: name})
+}
+
+// leaf writes an element with only text
+func (x *xmlWriter) leaf(local string, text string, attrs ...xml.Attr) {
+	x.start(xml.Name{Local: local}, attrs...)
+	x.token(xml.CharData(text))
+	x.end()
+}
+
+// flush writes what the encoder buffered and returns the first error
+func (x *xmlWriter) flush() error {
+	if x.err == nil {
+		x.err = x.enc.Flush()
+	}
+	return x.err
+}
+
+// el is the name of an element without namespace
+func el(local string) xml.Name {
+	return xml.Name{Local: local}
+}
@@ -0,0 +1,33 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific email, purpose and duration
+	CreateToken(email string, purpose string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
+
+// Supported token types
+const (
+	TypePaseto = "paseto"
+	TypeJWT    = "jwt"
+)
+
+// NewMaker creates the Maker for the given token type, defaults to PASETO
+func NewMaker(tokenType string, secretKey string) (Maker, error) {
+	switch tokenType {
+	case TypePaseto, "":
+		return NewPasetoMaker(secretKey)
+	case TypeJWT:
+		return NewJWTMaker(secretKey)
+	}
+
+	return nil, fmt.Errorf("unsupported token type: %s", tokenType)
+}
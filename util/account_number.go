@@ -0,0 +1,66 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccountNumberCountry starts the account numbers. It's a code ISO 3166 leaves to its users, so an
+// account number can't be taken for a real IBAN.
+const AccountNumberCountry = "XB"
+
+// accountNumberLength is the country, the 2 check digits and 12 digits that identify the account
+const accountNumberLength = 16
+
+// NormalizeAccountNumber removes the spaces account numbers are written with and upper cases them
+func NormalizeAccountNumber(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
+
+// IsValidAccountNumber checks the number has the format of the account numbers and its check digits
+// are right, like IBANs: moving the first 4 characters to the end, it's 1 modulo 97.
+// It catches any typo of a single character and most swaps of two.
+func IsValidAccountNumber(number string) bool {
+	if len(number) != accountNumberLength || !strings.HasPrefix(number, AccountNumberCountry) {
+		return false
+	}
+	for _, c := range number[len(AccountNumberCountry):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return mod97(number[4:]+number[:4]) == 1
+}
+
+// AccountNumberCheckDigits are the check digits of the account number with the 12 digits of bban
+func AccountNumberCheckDigits(bban string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bban+AccountNumberCountry+"00"))
+}
+
+// FormatAccountNumber writes the number in groups of 4 characters, the way people read it
+func FormatAccountNumber(number string) string {
+	var sb strings.Builder
+	for i, c := range number {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// mod97 is the remainder of the number made by s divided by 97, with the letters as numbers
+// from A = 10 to Z = 35
+func mod97(s string) int {
+	remainder := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
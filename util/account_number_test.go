@@ -0,0 +1,48 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountNumber(t *testing.T) {
+	number := RandomAccountNumber()
+	require.Len(t, number, accountNumberLength)
+	require.True(t, IsValidAccountNumber(number))
+
+	// the migration computes the same check digits, 000000000001 has 32
+	require.True(t, IsValidAccountNumber("XB32000000000001"))
+	require.Equal(t, "32", AccountNumberCheckDigits("000000000001"))
+
+	formatted := FormatAccountNumber(number)
+	require.Len(t, formatted, accountNumberLength+3)
+	require.Equal(t, number, NormalizeAccountNumber(formatted))
+	require.Equal(t, number, NormalizeAccountNumber(" "+strings.ToLower(formatted)+" "))
+}
+
+func TestInvalidAccountNumber(t *testing.T) {
+	number := RandomAccountNumber()
+
+	for i := 2; i < len(number); i++ {
+		for c := byte('0'); c <= '9'; c++ {
+			if c == number[i] {
+				continue
+			}
+			typo := number[:i] + string(c) + number[i+1:]
+			require.False(t, IsValidAccountNumber(typo), typo)
+		}
+	}
+
+	for _, invalid := range []string{
+		"",
+		number[:accountNumberLength-1],
+		number + "0",
+		"DE" + number[2:],
+		"XB12 3456 7890 1234",
+		"XB1234567890123A",
+	} {
+		require.False(t, IsValidAccountNumber(invalid), invalid)
+	}
+}
@@ -1,11 +1,31 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver                     string        `mapstructure:"DB_DRIVER"`
+	DBSource                     string        `mapstructure:"DB_SOURCE"`
+	ServerAddress                string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType                    string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey            string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration          time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration         time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	IdempotencyKeyRetention      time.Duration `mapstructure:"IDEMPOTENCY_KEY_RETENTION"`
+	FxRatesFile                  string        `mapstructure:"FX_RATES_FILE"`
+	FxQuoteDuration              time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	HoldDuration                 time.Duration `mapstructure:"HOLD_DURATION"`
+	SchedulerPollInterval        time.Duration `mapstructure:"SCHEDULER_POLL_INTERVAL"`
+	ScheduledTransferMaxAttempts int32         `mapstructure:"SCHEDULED_TRANSFER_MAX_ATTEMPTS"`
+	ScheduledTransferBackoff     time.Duration `mapstructure:"SCHEDULED_TRANSFER_RETRY_BACKOFF"`
+	ReconciliationInterval       time.Duration `mapstructure:"RECONCILIATION_INTERVAL"`
+	BalanceSnapshotInterval      time.Duration `mapstructure:"BALANCE_SNAPSHOT_INTERVAL"`
+	CurrencyRefreshInterval      time.Duration `mapstructure:"CURRENCY_REFRESH_INTERVAL"`
+	// AdminEmails are the users allowed into the admin endpoints, comma separated in the env
+	AdminEmails []string `mapstructure:"ADMIN_EMAILS"`
 }
 
 // LoadConfig maps the variables from the .env file to the Config struct
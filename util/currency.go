@@ -1,16 +1,89 @@
 package util
 
+import (
+	"sort"
+	"sync"
+)
+
 const (
 	ARS = "ARS"
 	USD = "USD"
 	EUR = "EUR"
 )
 
-func IsCurrencySuported(currency string) bool {
-	switch currency {
-	case ARS, USD, EUR:
-		return true
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code        string `json:"code"`
+	NumericCode string `json:"numeric_code"`
+	// Exponent is the number of decimals of the minor unit the amounts are in, 2 for cents
+	Exponent int32 `json:"exponent"`
+	// Enabled currencies are the ones accounts and transfers can be created in
+	Enabled bool `json:"enabled"`
+}
+
+// DefaultCurrencies are the currencies the database starts with
+var DefaultCurrencies = []Currency{
+	{Code: ARS, NumericCode: "032", Exponent: 2, Enabled: true},
+	{Code: EUR, NumericCode: "978", Exponent: 2, Enabled: true},
+	{Code: USD, NumericCode: "840", Exponent: 2, Enabled: true},
+}
+
+// CurrencyRegistry keeps the currencies in memory, so validating one doesn't take a query.
+// It's safe for concurrent use.
+type CurrencyRegistry struct {
+	mu         sync.RWMutex
+	currencies map[string]Currency
+}
+
+func NewCurrencyRegistry(currencies []Currency) *CurrencyRegistry {
+	r := &CurrencyRegistry{}
+	r.Replace(currencies)
+	return r
+}
+
+// Replace swaps all the currencies of the registry, the way it's refreshed from the database
+func (r *CurrencyRegistry) Replace(currencies []Currency) {
+	byCode := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	r.mu.Lock()
+	r.currencies = byCode
+	r.mu.Unlock()
+}
+
+// Set adds the currency or replaces the one with its code
+func (r *CurrencyRegistry) Set(currency Currency) {
+	r.mu.Lock()
+	r.currencies[currency.Code] = currency
+	r.mu.Unlock()
+}
+
+// Get returns the currency with the code, enabled or not
+func (r *CurrencyRegistry) Get(code string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currency, ok := r.currencies[code]
+	return currency, ok
+}
+
+// IsEnabled tells if accounts and transfers can be created in the currency
+func (r *CurrencyRegistry) IsEnabled(code string) bool {
+	currency, ok := r.Get(code)
+	return ok && currency.Enabled
+}
+
+// List returns all the currencies ordered by code
+func (r *CurrencyRegistry) List() []Currency {
+	r.mu.RLock()
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		currencies = append(currencies, currency)
 	}
+	r.mu.RUnlock()
 
-	return false
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
 }
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry := NewCurrencyRegistry(DefaultCurrencies)
+	require.Equal(t, DefaultCurrencies, registry.List())
+	require.True(t, registry.IsEnabled(USD))
+	require.False(t, registry.IsEnabled("JPY"))
+
+	usd, ok := registry.Get(USD)
+	require.True(t, ok)
+	usd.Enabled = false
+	registry.Set(usd)
+	require.False(t, registry.IsEnabled(USD))
+
+	// disabled currencies are still listed
+	got, ok := registry.Get(USD)
+	require.True(t, ok)
+	require.Equal(t, usd, got)
+	require.Len(t, registry.List(), len(DefaultCurrencies))
+
+	registry.Replace([]Currency{{Code: "JPY", NumericCode: "392", Exponent: 0, Enabled: true}})
+	require.True(t, registry.IsEnabled("JPY"))
+	require.False(t, registry.IsEnabled(ARS))
+	require.Len(t, registry.List(), 1)
+}
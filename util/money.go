@@ -0,0 +1,259 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxAmount is the largest amount in minor units a single operation can move. It leaves room in int64
+// to take up to 100% of it in basis points and to add fees on top.
+const MaxAmount int64 = 100_000_000_000_000
+
+var (
+	// ErrAmountOverflow is returned when an amount doesn't fit in int64 minor units
+	ErrAmountOverflow   = errors.New("amount out of range")
+	ErrCurrencyMismatch = errors.New("amounts in different currencies")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Money is an amount in the minor units of its currency, 400 is 4.00 in a currency with exponent 2
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount like "-12.34" in the currency. It takes up to as many decimals
+// as the exponent of the currency, a 2 decimals amount in a currency without minor unit is an error.
+func ParseMoney(s string, currency Currency) (Money, error) {
+	amount, err := ParseAmount(s, currency.Exponent)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+// Add sums two amounts of the same currency
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency.Code != o.Currency.Code {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency.Code, o.Currency.Code)
+	}
+
+	sum, err := AddAmounts(m.Amount, o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return NewMoney(sum, m.Currency), nil
+}
+
+// Sub subtracts o, of the same currency, from m
+func (m Money) Sub(o Money) (Money, error) {
+	neg, err := o.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m.Add(neg)
+}
+
+// Neg is the amount with the sign changed, the lowest int64 has no opposite
+func (m Money) Neg() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(-m.Amount, m.Currency), nil
+}
+
+// Mul multiplies the amount by n
+func (m Money) Mul(n int64) (Money, error) {
+	product, err := MulAmounts(m.Amount, n)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return NewMoney(product, m.Currency), nil
+}
+
+// Decimal writes the amount with the decimals of the currency and no grouping, 123456 is 1234.56.
+// It's the format of the files exchanged with other systems, ParseMoney reads it back.
+func (m Money) Decimal() string {
+	return FormatAmount(m.Amount, m.Currency.Exponent)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency.Code
+}
+
+// Format writes the amount for the readers of the locale, USD 1,234.56 in English or 1.234,56 USD in Spanish
+func (m Money) Format(locale Locale) string {
+	amount := formatDigits(m.Amount, m.Currency.Exponent, locale.Decimal, locale.Group)
+	if locale.CodeFirst {
+		return m.Currency.Code + " " + amount
+	}
+
+	return amount + " " + m.Currency.Code
+}
+
+// AddAmounts sums two amounts in minor units, failing instead of wrapping around
+func AddAmounts(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrAmountOverflow
+	}
+
+	return a + b, nil
+}
+
+// MulAmounts multiplies an amount in minor units by n, failing instead of wrapping around
+func MulAmounts(a, n int64) (int64, error) {
+	if a == 0 || n == 0 {
+		return 0, nil
+	}
+
+	product := a * n
+	if product/n != a || (a == -1 && n == math.MinInt64) || (n == -1 && a == math.MinInt64) {
+		return 0, ErrAmountOverflow
+	}
+
+	return product, nil
+}
+
+// ParseAmount reads a decimal amount into minor units of a currency with the exponent, "123.4" is 12340
+// with exponent 2. Only an optional minus sign, digits and a decimal point are accepted.
+func ParseAmount(s string, exponent int32) (int64, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimPrefix(s, "-")
+
+	units, fraction, point := strings.Cut(digits, ".")
+	if !isDigits(units) || (point && !isDigits(fraction)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fraction) > int(exponent) {
+		return 0, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, exponent)
+	}
+	fraction += strings.Repeat("0", int(exponent)-len(fraction))
+
+	// the sign is parsed with the digits, so the lowest int64 can be read
+	amount, err := strconv.ParseInt(s[:len(s)-len(digits)]+units+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOverflow, s)
+	}
+
+	return amount, nil
+}
+
+// FormatAmount writes an amount in minor units as a decimal with the exponent, 12345 is 123.45 with 2
+func FormatAmount(amount int64, exponent int32) string {
+	return formatDigits(amount, exponent, ".", "")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDigits writes the amount with exponent decimals after the decimal separator,
+// and the group separator, when there is one, every 3 digits of the units
+func formatDigits(amount int64, exponent int32, decimal string, group string) string {
+	sign := ""
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = -abs
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if pad := int(exponent) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	units, fraction := digits[:len(digits)-int(exponent)], digits[len(digits)-int(exponent):]
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range units {
+		if i > 0 && group != "" && (len(units)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(c)
+	}
+	if fraction != "" {
+		b.WriteString(decimal)
+		b.WriteString(fraction)
+	}
+
+	return b.String()
+}
+
+// Locale is how the amounts are written for the readers of a language
+type Locale struct {
+	Language string
+	Decimal  string
+	Group    string
+	// CodeFirst writes the currency code before the amount, otherwise it goes after
+	CodeFirst bool
+}
+
+var (
+	LocaleEnglish = Locale{Language: "en", Decimal: ".", Group: ",", CodeFirst: true}
+	LocaleSpanish = Locale{Language: "es", Decimal: ",", Group: "."}
+	LocaleGerman  = Locale{Language: "de", Decimal: ",", Group: "."}
+	LocaleFrench  = Locale{Language: "fr", Decimal: ",", Group: "\u202f"}
+)
+
+var locales = map[string]Locale{
+	LocaleEnglish.Language: LocaleEnglish,
+	LocaleSpanish.Language: LocaleSpanish,
+	LocaleGerman.Language:  LocaleGerman,
+	LocaleFrench.Language:  LocaleFrench,
+}
+
+// LookupLocale picks the locale of the language with the highest weight in an Accept-Language header,
+// es-AR;q=0.8 is Spanish. Regions aren't told apart, and without a known language it's English.
+func LookupLocale(acceptLanguage string) Locale {
+	type weighted struct {
+		locale Locale
+		q      float64
+	}
+
+	var candidates []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+		locale, ok := locales[strings.ToLower(language)]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, weighted{locale: locale, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return LocaleEnglish
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testUSD = Currency{Code: USD, NumericCode: "840", Exponent: 2, Enabled: true}
+	testJPY = Currency{Code: "JPY", NumericCode: "392", Exponent: 0, Enabled: true}
+	testBHD = Currency{Code: "BHD", NumericCode: "048", Exponent: 3, Enabled: true}
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		s        string
+		currency Currency
+		amount   int64
+		err      error
+	}{
+		{s: "12.34", currency: testUSD, amount: 1234},
+		{s: "12.3", currency: testUSD, amount: 1230},
+		{s: " 12 ", currency: testUSD, amount: 1200},
+		{s: "-0.05", currency: testUSD, amount: -5},
+		{s: "400", currency: testJPY, amount: 400},
+		{s: "1.005", currency: testBHD, amount: 1005},
+		{s: "92233720368547758.07", currency: testUSD, amount: math.MaxInt64},
+		{s: "-92233720368547758.08", currency: testUSD, amount: math.MinInt64},
+		{s: "92233720368547758.08", currency: testUSD, err: ErrAmountOverflow},
+		{s: "12.345", currency: testUSD, err: ErrInvalidAmount},
+		{s: "4.00", currency: testJPY, err: ErrInvalidAmount},
+		{s: "1,234.56", currency: testUSD, err: ErrInvalidAmount},
+		{s: "12.", currency: testUSD, err: ErrInvalidAmount},
+		{s: ".5", currency: testUSD, err: ErrInvalidAmount},
+		{s: "+5", currency: testUSD, err: ErrInvalidAmount},
+		{s: "-", currency: testUSD, err: ErrInvalidAmount},
+		{s: "", currency: testUSD, err: ErrInvalidAmount},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			money, err := ParseMoney(tc.s, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, NewMoney(tc.amount, tc.currency), money)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := NewMoney(1050, testUSD)
+
+	sum, err := a.Add(NewMoney(-2000, testUSD))
+	require.NoError(t, err)
+	require.Equal(t, int64(-950), sum.Amount)
+
+	diff, err := a.Sub(NewMoney(50, testUSD))
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), diff.Amount)
+
+	product, err := a.Mul(-3)
+	require.NoError(t, err)
+	require.Equal(t, int64(-3150), product.Amount)
+
+	_, err = a.Add(NewMoney(1, testJPY))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	max := NewMoney(math.MaxInt64, testUSD)
+	min := NewMoney(math.MinInt64, testUSD)
+	_, err = max.Add(NewMoney(1, testUSD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = min.Sub(NewMoney(1, testUSD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = NewMoney(0, testUSD).Sub(min)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = min.Neg()
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = max.Mul(2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = min.Mul(-1)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = NewMoney(-1, testUSD).Mul(math.MinInt64)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	scaled, err := MulAmounts(MaxAmount, 10000)
+	require.NoError(t, err)
+	require.Equal(t, MaxAmount*10000, scaled)
+	_, err = MulAmounts(math.MaxInt64/100, 250)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestMoneyFormat(t *testing.T) {
+	testCases := []struct {
+		money   Money
+		locale  Locale
+		decimal string
+		display string
+	}{
+		{money: NewMoney(123456789, testUSD), locale: LocaleEnglish, decimal: "1234567.89", display: "USD 1,234,567.89"},
+		{money: NewMoney(123456789, testUSD), locale: LocaleSpanish, decimal: "1234567.89", display: "1.234.567,89 USD"},
+		{money: NewMoney(-5, testUSD), locale: LocaleGerman, decimal: "-0.05", display: "-0,05 USD"},
+		{money: NewMoney(400, testJPY), locale: LocaleEnglish, decimal: "400", display: "JPY 400"},
+		{money: NewMoney(-1234567, testBHD), locale: LocaleFrench, decimal: "-1234.567", display: "-1\u202f234,567 BHD"},
+		{money: NewMoney(math.MinInt64, testUSD), locale: LocaleEnglish, decimal: "-92233720368547758.08", display: "USD -92,233,720,368,547,758.08"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.display, func(t *testing.T) {
+			require.Equal(t, tc.decimal, tc.money.Decimal())
+			require.Equal(t, tc.display, tc.money.Format(tc.locale))
+
+			// the decimal form reads back
+			parsed, err := ParseMoney(tc.money.Decimal(), tc.money.Currency)
+			require.NoError(t, err)
+			require.Equal(t, tc.money, parsed)
+		})
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	require.Equal(t, LocaleEnglish, LookupLocale(""))
+	require.Equal(t, LocaleEnglish, LookupLocale("ja-JP"))
+	require.Equal(t, LocaleSpanish, LookupLocale("es-AR,es;q=0.9,en;q=0.8"))
+	require.Equal(t, LocaleGerman, LookupLocale("en;q=0.5, DE-ch;q=0.7"))
+	require.Equal(t, LocaleFrench, LookupLocale("ja, fr;q=0.1"))
+	require.Equal(t, LocaleEnglish, LookupLocale("es;q=0"))
+}
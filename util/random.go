@@ -40,3 +40,9 @@ func RandomCurrency() string {
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
+
+// RandomAccountNumber generates a valid account number
+func RandomAccountNumber() string {
+	bban := fmt.Sprintf("%012d", RandomInt(0, 999_999_999_999))
+	return AccountNumberCountry + AccountNumberCheckDigits(bban) + bban
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/lib/pq"
+)
+
+// currenciesChannel is where the database notifies the changes of the currencies table
+const currenciesChannel = "currencies"
+
+// CurrencyStore is the part of the db store used by the currency refresher
+type CurrencyStore interface {
+	ListCurrencies(ctx context.Context) ([]db.Currencies, error)
+}
+
+// CurrencyRefresher loads the currencies of the database into the registry. The admin endpoints
+// update the registry of their own server, the refresher brings the changes made by the other ones.
+type CurrencyRefresher struct {
+	store    CurrencyStore
+	registry *util.CurrencyRegistry
+	interval time.Duration
+	dbSource string
+}
+
+// NewCurrencyRefresher creates a refresher that reloads the currencies when the table changes,
+// and every CurrencyRefreshInterval in case a notification was lost
+func NewCurrencyRefresher(config util.Config, store CurrencyStore, registry *util.CurrencyRegistry) *CurrencyRefresher {
+	return &CurrencyRefresher{
+		store:    store,
+		registry: registry,
+		interval: config.CurrencyRefreshInterval,
+		dbSource: config.DBSource,
+	}
+}
+
+// Refresh replaces the currencies of the registry with the ones of the database
+func (r *CurrencyRefresher) Refresh(ctx context.Context) error {
+	rows, err := r.store.ListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	currencies := make([]util.Currency, len(rows))
+	for i, row := range rows {
+		currencies[i] = row.RegistryCurrency()
+	}
+	r.registry.Replace(currencies)
+
+	return nil
+}
+
+// Start refreshes the registry until ctx is done, it's meant to be run in its own goroutine.
+// It listens to the notifications a trigger sends on every change of the currencies table, without
+// them it only polls. When a refresh fails the registry keeps the currencies it had.
+func (r *CurrencyRefresher) Start(ctx context.Context) {
+	listener := pq.NewListener(r.dbSource, time.Second, time.Minute, nil)
+	defer listener.Close()
+
+	// Listen waits for the connection, the registry is polled meanwhile
+	go func() {
+		if err := listener.Listen(currenciesChannel); err != nil {
+			log.Println("cannot listen to the changes of the currencies:", err)
+		}
+	}()
+
+	r.run(ctx, listener.Notify)
+}
+
+// run refreshes the registry on every change and every interval until ctx is done. The listener sends
+// a nil notification after it reconnects, the changes in between were missed so it refreshes too.
+func (r *CurrencyRefresher) run(ctx context.Context, changes <-chan *pq.Notification) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+
+		if err := r.Refresh(ctx); err != nil {
+			log.Println("cannot refresh the currencies:", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCurrencyStore lists its currencies or fails with err
+type fakeCurrencyStore struct {
+	currencies []db.Currencies
+	err        error
+}
+
+func (f *fakeCurrencyStore) ListCurrencies(ctx context.Context) ([]db.Currencies, error) {
+	return f.currencies, f.err
+}
+
+func TestCurrencyRefresh(t *testing.T) {
+	store := &fakeCurrencyStore{currencies: []db.Currencies{
+		{Code: util.EUR, NumericCode: "978", Exponent: 2, Enabled: true},
+		{Code: util.USD, NumericCode: "840", Exponent: 2, Enabled: false},
+	}}
+	registry := util.NewCurrencyRegistry(util.DefaultCurrencies)
+	refresher := NewCurrencyRefresher(util.Config{}, store, registry)
+
+	require.NoError(t, refresher.Refresh(context.Background()))
+	require.True(t, registry.IsEnabled(util.EUR))
+	require.False(t, registry.IsEnabled(util.USD))
+	require.False(t, registry.IsEnabled(util.ARS))
+	require.Len(t, registry.List(), 2)
+
+	// a failed refresh keeps the currencies the registry had
+	store.err = sql.ErrConnDone
+	require.ErrorIs(t, refresher.Refresh(context.Background()), sql.ErrConnDone)
+	require.True(t, registry.IsEnabled(util.EUR))
+	require.Len(t, registry.List(), 2)
+}
+
+func TestCurrencyRefreshOnChange(t *testing.T) {
+	store := &fakeCurrencyStore{currencies: []db.Currencies{
+		{Code: util.EUR, NumericCode: "978", Exponent: 2, Enabled: false},
+	}}
+	registry := util.NewCurrencyRegistry(util.DefaultCurrencies)
+	// the interval is too long to refresh during the test, only the notification does
+	refresher := NewCurrencyRefresher(util.Config{CurrencyRefreshInterval: time.Hour}, store, registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan *pq.Notification)
+	go refresher.run(ctx, changes)
+
+	changes <- &pq.Notification{Channel: currenciesChannel}
+	require.Eventually(t, func() bool {
+		return !registry.IsEnabled(util.EUR)
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, registry.List(), 1)
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+)
+
+// ReconcilerStore is the part of the db store used by the reconciler
+type ReconcilerStore interface {
+	ReconcileTx(ctx context.Context) (db.ReconciliationResult, error)
+}
+
+// Reconciler checks the ledger periodically and reports the discrepancies it finds
+type Reconciler struct {
+	store    ReconcilerStore
+	interval time.Duration
+}
+
+// NewReconciler creates a reconciler that checks the ledger every ReconciliationInterval
+func NewReconciler(config util.Config, store ReconcilerStore) *Reconciler {
+	return &Reconciler{
+		store:    store,
+		interval: config.ReconciliationInterval,
+	}
+}
+
+// Start reconciles the ledger until ctx is done, it's meant to be run in its own goroutine
+func (r *Reconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := r.Reconcile(ctx)
+		if err != nil {
+			log.Println("cannot reconcile the ledger:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile runs the reconciliation once and logs a summary, the discrepancies are in the report
+func (r *Reconciler) Reconcile(ctx context.Context) (db.ReconciliationResult, error) {
+	result, err := r.store.ReconcileTx(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	report := result.Report
+	if report.Discrepancies > 0 {
+		log.Printf("reconciliation report %d: %d discrepancies in %d accounts and %d transfers",
+			report.ID, report.Discrepancies, report.AccountsChecked, report.TransfersChecked)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReconcilerStore counts the reconciliations and fails them with err
+type fakeReconcilerStore struct {
+	err   error
+	calls int
+}
+
+func (f *fakeReconcilerStore) ReconcileTx(ctx context.Context) (db.ReconciliationResult, error) {
+	f.calls++
+	return db.ReconciliationResult{
+		Report: db.ReconciliationReports{ID: int64(f.calls), Discrepancies: 1},
+	}, f.err
+}
+
+func TestReconcile(t *testing.T) {
+	config := util.Config{ReconciliationInterval: time.Hour}
+
+	store := &fakeReconcilerStore{}
+	result, err := NewReconciler(config, store).Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Report.ID)
+
+	store = &fakeReconcilerStore{err: errors.New("connection refused")}
+	_, err = NewReconciler(config, store).Reconcile(context.Background())
+	require.Error(t, err)
+}
+
+func TestReconcilerStartStops(t *testing.T) {
+	config := util.Config{ReconciliationInterval: time.Millisecond}
+	store := &fakeReconcilerStore{err: errors.New("connection refused")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		NewReconciler(config, store).Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reconciler didn't stop with its context")
+	}
+	// an error doesn't stop it
+	require.Greater(t, store.calls, 1)
+}
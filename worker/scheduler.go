@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+)
+
+// Store is the part of the db store used by the scheduler
+type Store interface {
+	RunDueScheduledTransferTx(ctx context.Context, policy db.RetryPolicy) (db.ScheduledTransferRunResult, error)
+}
+
+// TransferScheduler runs the scheduled transfers when they are due
+type TransferScheduler struct {
+	store        Store
+	policy       db.RetryPolicy
+	pollInterval time.Duration
+}
+
+// NewTransferScheduler creates a scheduler that checks for due transfers every SchedulerPollInterval
+func NewTransferScheduler(config util.Config, store Store) *TransferScheduler {
+	return &TransferScheduler{
+		store: store,
+		policy: db.RetryPolicy{
+			MaxAttempts: config.ScheduledTransferMaxAttempts,
+			Backoff:     config.ScheduledTransferBackoff,
+		},
+		pollInterval: config.SchedulerPollInterval,
+	}
+}
+
+// Start runs the due transfers until ctx is done, it's meant to be run in its own goroutine
+func (s *TransferScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue runs transfers one at a time until none is due. A database error stops it
+// until the next tick, the transfer that hit it wasn't claimed and is picked up again.
+func (s *TransferScheduler) runDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		result, err := s.store.RunDueScheduledTransferTx(ctx, s.policy)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Println("cannot run scheduled transfer:", err)
+			}
+			return
+		}
+
+		if result.Run.Error.Valid {
+			log.Printf("scheduled transfer %d failed attempt %d: %s",
+				result.ScheduledTransfer.ID, result.Run.Attempt, result.Run.Error.String)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/homocode/bank_demo/db/sqlc"
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore returns the queued errors, one per call, and sql.ErrNoRows when they run out
+type fakeStore struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeStore) RunDueScheduledTransferTx(ctx context.Context, policy db.RetryPolicy) (db.ScheduledTransferRunResult, error) {
+	f.calls++
+	if len(f.errs) == 0 {
+		return db.ScheduledTransferRunResult{}, sql.ErrNoRows
+	}
+
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return db.ScheduledTransferRunResult{}, err
+}
+
+func TestRunDue(t *testing.T) {
+	config := util.Config{SchedulerPollInterval: time.Minute}
+
+	// runs until nothing is due
+	store := &fakeStore{errs: []error{nil, nil, nil}}
+	NewTransferScheduler(config, store).runDue(context.Background())
+	require.Equal(t, 4, store.calls)
+
+	// stops at a database error
+	store = &fakeStore{errs: []error{nil, errors.New("connection refused"), nil}}
+	NewTransferScheduler(config, store).runDue(context.Background())
+	require.Equal(t, 2, store.calls)
+}
+
+func TestStartStops(t *testing.T) {
+	config := util.Config{SchedulerPollInterval: time.Millisecond}
+	store := &fakeStore{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		NewTransferScheduler(config, store).Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler didn't stop with its context")
+	}
+	require.Greater(t, store.calls, 1)
+}
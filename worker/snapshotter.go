@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+)
+
+// snapshotDelay is how long after a day ends its balances are snapshotted. Entries are stamped
+// when their transaction starts, one that began before midnight can still be committing after it.
+const snapshotDelay = time.Hour
+
+// SnapshotStore is the part of the db store used by the balance snapshotter
+type SnapshotStore interface {
+	CreateBalanceSnapshots(ctx context.Context, day time.Time) (int64, error)
+}
+
+// BalanceSnapshotter takes the end of day balances of the accounts once the day is over
+type BalanceSnapshotter struct {
+	store    SnapshotStore
+	interval time.Duration
+}
+
+// NewBalanceSnapshotter creates a snapshotter that checks for a day to snapshot every BalanceSnapshotInterval
+func NewBalanceSnapshotter(config util.Config, store SnapshotStore) *BalanceSnapshotter {
+	return &BalanceSnapshotter{
+		store:    store,
+		interval: config.BalanceSnapshotInterval,
+	}
+}
+
+// Start snapshots the balances until ctx is done, it's meant to be run in its own goroutine
+func (s *BalanceSnapshotter) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.snapshot(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot takes the balances of the last day that ended before snapshotDelay, the accounts that
+// already have a snapshot of it are skipped so it can run many times a day
+func (s *BalanceSnapshotter) snapshot(ctx context.Context, now time.Time) {
+	day := lastClosedDay(now)
+
+	created, err := s.store.CreateBalanceSnapshots(ctx, day)
+	if err != nil {
+		log.Println("cannot snapshot the balances:", err)
+		return
+	}
+
+	if created > 0 {
+		log.Printf("snapshotted the balances of %d accounts on %s", created, day.Format("2006-01-02"))
+	}
+}
+
+// lastClosedDay is the last day, at midnight in UTC, that ended at least snapshotDelay before now
+func lastClosedDay(now time.Time) time.Time {
+	year, month, day := now.Add(-snapshotDelay).UTC().Date()
+	return time.Date(year, month, day-1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/homocode/bank_demo/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSnapshotStore records the days it's asked to snapshot
+type fakeSnapshotStore struct {
+	days []time.Time
+}
+
+func (f *fakeSnapshotStore) CreateBalanceSnapshots(ctx context.Context, day time.Time) (int64, error) {
+	f.days = append(f.days, day)
+	return 1, nil
+}
+
+func TestLastClosedDay(t *testing.T) {
+	testCases := []struct {
+		name string
+		now  time.Time
+		day  time.Time
+	}{
+		{
+			name: "AfterDelay",
+			now:  time.Date(2023, time.March, 4, 1, 30, 0, 0, time.UTC),
+			day:  time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "WithinDelay",
+			now:  time.Date(2023, time.March, 4, 0, 30, 0, 0, time.UTC),
+			day:  time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "MonthBoundary",
+			now:  time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC),
+			day:  time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "OtherTimeZone",
+			now:  time.Date(2023, time.March, 4, 1, 30, 0, 0, time.FixedZone("ART", -3*60*60)),
+			day:  time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.day, lastClosedDay(tc.now))
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	store := &fakeSnapshotStore{}
+	snapshotter := NewBalanceSnapshotter(util.Config{BalanceSnapshotInterval: time.Hour}, store)
+
+	now := time.Date(2023, time.March, 4, 12, 0, 0, 0, time.UTC)
+	snapshotter.snapshot(context.Background(), now)
+	snapshotter.snapshot(context.Background(), now.Add(time.Hour))
+
+	// the same day until the next one is over
+	day := time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []time.Time{day, day}, store.days)
+}